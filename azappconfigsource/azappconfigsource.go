@@ -0,0 +1,146 @@
+// Package azappconfigsource provides a configuration source reading the key-values below a key
+// prefix of an Azure App Configuration store:
+//
+//	client, err := azappconfig.NewClientFromConnectionString(connStr, nil)
+//	src := azappconfigsource.New(client, "myapp:", "", "production")
+//	config.Config().StartRefresh(ctx, src, time.Minute)
+//
+// The keys below the prefix are split at Separator into nested configuration keys, so the key
+// "myapp:db:host" sets "host" inside the object "db". Values are decoded with
+// config.ParseValue.
+//
+// Key-values are read for each of the labels passed to New in order, the empty label selecting
+// key-values without a label, and values of later labels replace those of earlier ones. This
+// layers environment specific values over shared defaults.
+//
+// Feature flags, the key-values below ".appconfig.featureflag/", are read as well when
+// FeatureFlags is set. Each flag is stored as a boolean below the "features" key, so the flag
+// "beta" sets "features.beta" to whether it is enabled.
+package azappconfigsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+const (
+	// featureFlagPrefix is the key prefix App Configuration stores feature flags under.
+	featureFlagPrefix = ".appconfig.featureflag/"
+
+	// featuresKey is the configuration key feature flags are stored below.
+	featuresKey = "features"
+)
+
+// Source is a config.Source reading key-values from an Azure App Configuration store.
+type Source struct {
+	Client       *azappconfig.Client
+	Prefix       string   // key prefix of the key-values to read
+	Labels       []string // labels to read in order, empty for key-values without a label
+	Separator    string   // separator of nested keys, ":" if empty
+	FeatureFlags bool     // read feature flags into the "features" key
+}
+
+// New returns a Source reading the key-values below prefix with the given labels in order using
+// client. Without labels only key-values without a label are read.
+func New(client *azappconfig.Client, prefix string, labels ...string) *Source {
+	if len(labels) == 0 {
+		labels = []string{""}
+	}
+	return &Source{Client: client, Prefix: prefix, Labels: labels}
+}
+
+// Name identifies the source by its prefix and labels.
+func (s *Source) Name() string {
+	return "azappconfig:" + s.Prefix + "@" + strings.Join(s.Labels, ",")
+}
+
+// Load reads the current key-values.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	sep := s.Separator
+	if sep == "" {
+		sep = ":"
+	}
+	flat := make(map[string]any)
+	features := make(map[string]any)
+	for _, label := range s.Labels {
+		err := s.list(ctx, s.Prefix+"*", label, func(setting azappconfig.Setting) error {
+			if strings.HasPrefix(*setting.Key, featureFlagPrefix) {
+				return nil
+			}
+			key := strings.Trim(strings.TrimPrefix(*setting.Key, s.Prefix), sep)
+			flat[key] = nil
+			if setting.Value != nil {
+				flat[key] = config.ParseValue([]byte(*setting.Value))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !s.FeatureFlags {
+			continue
+		}
+		err = s.list(ctx, featureFlagPrefix+"*", label, func(setting azappconfig.Setting) error {
+			var flag struct {
+				ID      string `json:"id"`
+				Enabled bool   `json:"enabled"`
+			}
+			if setting.Value == nil || json.Unmarshal([]byte(*setting.Value), &flag) != nil {
+				return fmt.Errorf("invalid feature flag %s", *setting.Key)
+			}
+			if flag.ID == "" {
+				flag.ID = strings.TrimPrefix(*setting.Key, featureFlagPrefix)
+			}
+			features[flag.ID] = flag.Enabled
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := config.Nest(flat, sep)
+	if err != nil {
+		return nil, err
+	}
+	if s.FeatureFlags {
+		if _, ok := doc[featuresKey]; ok {
+			return nil, fmt.Errorf("key %q conflicts with feature flags", featuresKey)
+		}
+		doc[featuresKey] = features
+	}
+	return doc, nil
+}
+
+// list calls fn for every setting matching the key filter and label.
+func (s *Source) list(ctx context.Context, keyFilter, label string, fn func(azappconfig.Setting) error) error {
+	if label == "" {
+		// App Configuration selects settings without a label with the null character
+		label = "\x00"
+	}
+	pager := s.Client.NewListSettingsPager(azappconfig.SettingSelector{
+		KeyFilter:   &keyFilter,
+		LabelFilter: &label,
+	}, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, setting := range page.Settings {
+			if setting.Key == nil {
+				continue
+			}
+			if err := fn(setting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
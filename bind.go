@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Binding holds a value of type T decoded from the configuration, such as a struct, and replaces
+// it whenever the configuration changes, so long running components always see current values
+// without polling. The value is replaced as a whole, so readers never see a partially updated
+// value. Create bindings with Bind.
+type Binding[T any] struct {
+	c    *Configuration
+	key  string
+	opts []UnmarshalOption
+	val  atomic.Pointer[T]
+	stop func()
+
+	mu  sync.Mutex // serializes updates
+	fns []func(old, new *T)
+}
+
+// Bind decodes the value of key, or the whole configuration if key is empty, into a new value of
+// type T like UnmarshalKey and Unmarshal do, and returns a binding that decodes it again after
+// every change of the configuration. It fails if the initial value cannot be decoded. Values
+// that cannot be decoded after a change are passed to the callback error handler, see
+// SetCallbackErrorHandler, and the binding keeps its previous value. Call Close to stop updating
+// the binding.
+func Bind[T any](c *Configuration, key string, opts ...UnmarshalOption) (*Binding[T], error) {
+	b := &Binding[T]{c: c, key: key, opts: opts}
+	val, err := b.decode()
+	if err != nil {
+		return nil, err
+	}
+	b.val.Store(val)
+	b.stop = c.subscribe(&subscriber{key: key, onReload: b.update, all: true}, nil)
+	return b, nil
+}
+
+// Load returns the current value of the binding. It is shared with other callers and must not be
+// modified.
+func (b *Binding[T]) Load() *T {
+	return b.val.Load()
+}
+
+// OnChange registers fn to be called with the previous and the new value whenever the value of
+// the binding changes. fn is called by the goroutine that changed the configuration, after the
+// value was replaced; calls for successive changes are not concurrent.
+func (b *Binding[T]) OnChange(fn func(old, new *T)) {
+	b.mu.Lock()
+	b.fns = append(b.fns, fn)
+	b.mu.Unlock()
+}
+
+// Close stops updating the binding. Its last value remains available with Load.
+func (b *Binding[T]) Close() {
+	b.stop()
+}
+
+// decode decodes the current value of the binding.
+func (b *Binding[T]) decode() (*T, error) {
+	val := new(T)
+	var err error
+	if b.key == "" {
+		err = b.c.Unmarshal(val, b.opts...)
+	} else {
+		err = b.c.UnmarshalKey(b.key, val, b.opts...)
+	}
+	return val, err
+}
+
+// update replaces the value of the binding after a change of the configuration.
+func (b *Binding[T]) update([]Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	val, err := b.decode()
+	if err != nil {
+		b.c.handleCallbackError(fmt.Errorf("config: updating binding of %q: %w", b.key, err))
+		return
+	}
+	old := b.val.Load()
+	if reflect.DeepEqual(old, val) {
+		return
+	}
+	b.val.Store(val)
+	for _, fn := range b.fns {
+		fn(old, val)
+	}
+}
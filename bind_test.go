@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	dir := t.TempDir()
+	fname := writeFile(t, dir, "app.json", `{"db": {"host": "a", "port": 1}, "name": "app"}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	var cbErr error
+	c.SetCallbackErrorHandler(func(err error) { cbErr = err })
+
+	b, err := Bind[testServer](c, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Load(); got.Host != "a" || got.Port != 1 {
+		t.Errorf("got %+v, want the initial value", got)
+	}
+	var changes [][2]string
+	b.OnChange(func(old, new *testServer) { changes = append(changes, [2]string{old.Host, new.Host}) })
+
+	writeFile(t, dir, "app.json", `{"db": {"host": "b", "port": 1}, "name": "app"}`)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Load().Host; got != "b" {
+		t.Errorf("after Reload: got host %q", got)
+	}
+	c.Set("db.host", "c")
+	if got := b.Load().Host; got != "c" {
+		t.Errorf("after Set: got host %q", got)
+	}
+	c.Set("name", "other")
+	if want := [][2]string{{"a", "b"}, {"b", "c"}}; len(changes) != 2 || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("got changes %v, want %v without the change of another key", changes, want)
+	}
+
+	c.Set("db.port", "http")
+	if got := b.Load().Port; got != 1 || cbErr == nil {
+		t.Errorf("got port %d and error %v, want the previous value kept and the error handled", got, cbErr)
+	}
+
+	b.Close()
+	c.Set("db.port", 2)
+	if got := b.Load().Port; got != 1 {
+		t.Errorf("got port %d, want the binding not updated after Close", got)
+	}
+
+	all, err := Bind[testApp](c, "")
+	if err != nil || all.Load().Name != "other" {
+		t.Errorf("got %v, %v binding the whole configuration", all.Load(), err)
+	}
+	if _, err := Bind[testServer](c, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("got %v, want ErrKeyNotFound for a missing key", err)
+	}
+}
+
+func TestBindConcurrentReaders(t *testing.T) {
+	c := New()
+	c.Set("db", map[string]any{"host": "h0", "port": 0})
+	b, err := Bind[testServer](c, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			c.SetAll(map[string]any{"db.host": "h" + strconv.Itoa(i%10), "db.port": i % 10})
+		}
+	}()
+	for range 100 {
+		if v := b.Load(); v.Host != "h"+strconv.Itoa(int(v.Port)) {
+			t.Fatalf("observed a partially updated value %+v", v)
+		}
+	}
+	wg.Wait()
+}
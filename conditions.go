@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// whenKey is the reserved key of the conditional sections of a configuration document. Like
+// "$include" it cannot clash with ordinary keys such as "when".
+const whenKey = "$when"
+
+// facts holds the fact providers conditional sections are evaluated against.
+var facts = struct {
+	sync.RWMutex
+	m map[string]func() string
+}{
+	m: map[string]func() string{
+		"os":   func() string { return runtime.GOOS },
+		"arch": func() string { return runtime.GOARCH },
+		"hostname": func() string {
+			name, _ := os.Hostname()
+			return name
+		},
+	},
+}
+
+// RegisterFact registers a fact that conditional sections of configuration documents can test,
+// replacing any fact of the same name. The facts "os", "arch" and "hostname" are predefined.
+//
+// A document may hold a top level "$when" array of conditional sections, which are evaluated when
+// the document is loaded and then removed from it. Each section holds a "set" object and
+// conditions naming facts: a condition "<fact>": "value" matches if the fact equals the value and
+// a condition "<fact>_glob": "pattern" matches if the fact matches the path.Match pattern. The
+// "set" objects of all sections whose conditions all match are deep-merged over the document in
+// the order of the sections. Conditions on unknown facts fail the load.
+//
+//	"$when": [
+//		{"os": "windows", "set": {"data_dir": "C:\\data"}},
+//		{"hostname_glob": "canary-*", "set": {"debug": true}}
+//	]
+func RegisterFact(name string, fn func() string) {
+	facts.Lock()
+	facts.m[name] = fn
+	facts.Unlock()
+}
+
+// applyConditions merges the matching conditional sections of a decoded document into it and
+// removes the sections.
+func applyConditions(keyvals map[string]any) (map[string]any, error) {
+	when, ok := keyvals[whenKey]
+	if !ok {
+		return keyvals, nil
+	}
+	sections, ok := resolve(when).([]any)
+	if !ok {
+		return nil, fmt.Errorf("config: %q must be an array", whenKey)
+	}
+	delete(keyvals, whenKey)
+
+	for i, s := range sections {
+		section, ok := s.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("config: %s[%d] must be an object", whenKey, i)
+		}
+		match, err := matchSection(section)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s[%d]: %w", whenKey, i, err)
+		}
+		if match {
+			deepMerge(keyvals, deepCopyMap(section["set"].(map[string]any)))
+		}
+	}
+	return keyvals, nil
+}
+
+// matchSection reports whether all conditions of a conditional section match.
+func matchSection(section map[string]any) (bool, error) {
+	if _, ok := section["set"].(map[string]any); !ok {
+		return false, fmt.Errorf("%q must be an object", "set")
+	}
+	facts.RLock()
+	defer facts.RUnlock()
+	match := true
+	for _, cond := range sortedKeys(section) {
+		if cond == "set" {
+			continue
+		}
+		want, ok := section[cond].(string)
+		if !ok {
+			return false, fmt.Errorf("condition %q must be a string", cond)
+		}
+		name, glob := strings.CutSuffix(cond, "_glob")
+		fact, ok := facts.m[name]
+		if !ok {
+			return false, fmt.Errorf("unknown condition %q", cond)
+		}
+		if !glob {
+			match = match && fact() == want
+			continue
+		}
+		ok, err := path.Match(want, fact())
+		if err != nil {
+			return false, fmt.Errorf("condition %q: %w", cond, err)
+		}
+		match = match && ok
+	}
+	return match, nil
+}
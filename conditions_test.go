@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// setFact registers the fact name with value for the duration of the test.
+func setFact(t *testing.T, name, value string) {
+	t.Helper()
+	RegisterFact(name, func() string { return value })
+	t.Cleanup(func() {
+		facts.Lock()
+		delete(facts.m, name)
+		facts.Unlock()
+	})
+}
+
+func TestConditions(t *testing.T) {
+	setFact(t, "env", "prod")
+	setFact(t, "zone", "eu-west-1b")
+
+	fname := writeFile(t, t.TempDir(), "app.json", `{
+		"when": "always",
+		"db": {"host": "localhost", "pool": 5},
+		"$when": [
+			{"env": "prod", "set": {"db": {"host": "db.prod"}}},
+			{"env": "dev", "set": {"debug": true}},
+			{"env": "prod", "zone_glob": "eu-*", "set": {"db": {"pool": 20}}},
+			{"env": "prod", "zone_glob": "us-*", "set": {"db": {"pool": 50}}}
+		]
+	}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.host"); got != "db.prod" {
+		t.Errorf("got db.host %q, want db.prod", got)
+	}
+	if got := c.GetInt("db.pool"); got != 20 {
+		t.Errorf("got db.pool %d, want 20", got)
+	}
+	if c.Exists("debug") {
+		t.Error("section of a non-matching condition was applied")
+	}
+	if got := c.GetStr("when"); got != "always" {
+		t.Errorf("got ordinary key when %q, want always", got)
+	}
+	if c.Exists(whenKey) {
+		t.Errorf("%s was not removed", whenKey)
+	}
+}
+
+func TestConditionsErrors(t *testing.T) {
+	setFact(t, "env", "prod")
+
+	tests := []struct {
+		doc, err string
+	}{
+		{`{"$when": {}}`, "must be an array"},
+		{`{"$when": [1]}`, "must be an object"},
+		{`{"$when": [{"env": "prod"}]}`, `"set" must be an object`},
+		{`{"$when": [{"env": 1, "set": {}}]}`, `condition "env" must be a string`},
+		{`{"$when": [{"region": "eu", "set": {}}]}`, `unknown condition "region"`},
+		{`{"$when": [{"env_glob": "[", "set": {}}]}`, `condition "env_glob"`},
+	}
+	for _, tt := range tests {
+		err := New().ValidateBytes([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+}
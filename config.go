@@ -1,9 +1,13 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
+	"maps"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,8 +15,42 @@ import (
 
 // Configuration holds the Configuration key-value pairs and provides thread-safe access.
 type Configuration struct {
-	mu      sync.RWMutex
-	keyvals map[string]any
+	mu        sync.RWMutex
+	keyvals   map[string]any         // effective configuration
+	layers    *layers                // layers above the loaded documents, nil until used
+	autoEnv   *autoEnv               // settings of AutomaticEnv, nil if not enabled
+	envBinds  map[string][]string    // environment variables bound with BindEnv by key
+	flagBinds map[string]flagBinding // command line flags bound with BindFlag by key
+	defaults  map[string]any         // default values by key
+	descs     map[string]string      // key descriptions
+	required  map[string]bool        // keys declared as required with Require
+	lazy      bool                   // decode loaded documents lazily
+	keyDelim  string                 // delimiter of key paths, "." if empty
+
+	version     int64       // number of changes made to the configuration
+	history     []*Snapshot // states of the last versions, oldest first
+	historySize int         // number of versions kept in history, 0 if disabled
+
+	frozen       bool // changes made by Set and the like are rejected, see Freeze
+	strictFreeze bool // rejected changes panic, see FreezeStrict
+
+	profile     string                    // selected profile
+	profileBase map[string]any            // configuration outside the profiles section
+	profiles    map[string]map[string]any // profiles section, nil until a profile is selected
+
+	namespaces map[string]bool          // reserved key prefixes
+	nsHook     func(key, prefix string) // called for changes inside namespaces made outside them
+
+	files       []fileSource // files the configuration was read from, in load order
+	fileProfile string       // profile selecting overlay files, see SetFileProfile
+	srcHash     string       // hex encoded SHA-256 of the last loaded source document
+
+	metricsMu sync.Mutex
+	metrics   Metrics
+
+	subsMu     sync.Mutex
+	subs       []*subscriber
+	errHandler func(error)
 }
 
 // configtype defines the types that can be used in the configuration.
@@ -30,6 +68,57 @@ func Config() *Configuration {
 	return &config
 }
 
+// New returns an empty configuration independent of the global one, such as for libraries and
+// tests. Its methods read and access it like the package level functions do the global
+// configuration.
+func New() *Configuration {
+	return &Configuration{keyvals: make(map[string]any)}
+}
+
+// Clone returns a deep copy of c sharing no maps or slices with it, so the copy can be changed,
+// such as with per-tenant overrides, without affecting c. The copy has the values of all layers,
+// defaults, descriptions, bindings, settings, history, namespace reservations and the files c was
+// read from, so it can be reloaded on its own, but no change callbacks, namespace hook or metrics.
+// The copy of a frozen configuration is not frozen.
+func (c *Configuration) Clone() *Configuration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := &Configuration{
+		keyvals:     deepCopyMap(c.keyvals),
+		envBinds:    make(map[string][]string, len(c.envBinds)),
+		flagBinds:   maps.Clone(c.flagBinds),
+		defaults:    deepCopyMap(c.defaults),
+		descs:       maps.Clone(c.descs),
+		required:    maps.Clone(c.required),
+		lazy:        c.lazy,
+		keyDelim:    c.keyDelim,
+		version:     c.version,
+		history:     slices.Clone(c.history),
+		historySize: c.historySize,
+		profile:     c.profile,
+		profileBase: deepCopyMap(c.profileBase),
+		profiles:    cloneProfiles(c.profiles),
+		namespaces:  maps.Clone(c.namespaces),
+		files:       slices.Clone(c.files),
+		fileProfile: c.fileProfile,
+		srcHash:     c.srcHash,
+	}
+	if n.keyvals == nil {
+		n.keyvals = make(map[string]any)
+	}
+	if n.layers = c.layers.clone(); n.layers != nil {
+		n.keyvals = n.layers.compose()
+	}
+	if c.autoEnv != nil {
+		a := *c.autoEnv
+		n.autoEnv = &a
+	}
+	for key, vars := range c.envBinds {
+		n.envBinds[key] = slices.Clone(vars)
+	}
+	return n
+}
+
 // must is a helper function that panics if an error is encountered.
 func must[T any](res T, err error) T {
 	if err != nil {
@@ -38,20 +127,153 @@ func must[T any](res T, err error) T {
 	return res
 }
 
-// ReadFile reads a JSON configuration file and updates the global configuration.
+// ReadFile reads a configuration file into the global configuration like Configuration.ReadFile
+// but panics if the file cannot be read or decoded, use ReadFileE to handle these errors.
 func ReadFile(fname string) *Configuration {
-	cfgFile := must(os.Open(fname))
-	defer cfgFile.Close()
-	if err := json.NewDecoder(cfgFile).Decode(&config.keyvals); err != nil {
-		panic(err)
+	return must(ReadFileE(fname))
+}
+
+// ReadFileE is like ReadFile but returns an error instead of panicking.
+func ReadFileE(fname string) (*Configuration, error) {
+	if err := config.ReadFile(fname); err != nil {
+		return nil, err
 	}
-	return &config
+	return &config, nil
+}
+
+// ReadFile reads a configuration file in the format registered for its extension, such as YAML
+// for ".yaml" and ".yml" files and TOML for ".toml" files, JSON by default, and updates c. The
+// overlay file of the selected profile is layered over it, see SetFileProfile. It fails with an
+// error matching fs.ErrNotExist for a missing file or one naming the file for a malformed
+// document, leaving the configuration unchanged.
+func (c *Configuration) ReadFile(fname string) error {
+	return c.readLayered(fname, "", c.profileOverlays(fname)...)
+}
+
+// merge copies keyvals into the configuration and records the files and the hash of their source
+// document. If a profile is selected, it is applied again to the result, see SelectProfile.
+func (c *Configuration) merge(keyvals map[string]any, files []fileSource, srcHash string) error {
+	return c.mergeProfile(keyvals, files, srcHash, nil)
+}
+
+// mergeProfile implements merge and, if profile is not nil, selects the profile *profile in the
+// result. It fails without changing the configuration if the profile is unknown.
+func (c *Configuration) mergeProfile(keyvals map[string]any, files []fileSource, srcHash string, profile *string) error {
+	notify := c.hasSubscribers()
+	var old map[string]any
+	c.mu.Lock()
+	if c.profiles != nil || profile != nil {
+		name := c.profile
+		if profile != nil {
+			name = *profile
+		}
+		doc, err := c.mergeProfiled(keyvals, name)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		old = c.keyvals
+		c.setFileKeyvals(doc)
+		new := c.keyvals
+		c.addFiles(files)
+		c.srcHash = srcHash
+		c.record()
+		c.mu.Unlock()
+		if notify {
+			c.notify(newDelta(old, new, true))
+		}
+		return nil
+	}
+	if c.layers != nil {
+		// values of higher layers may hide the loaded ones, so the effective change is computed
+		old = c.keyvals
+		for key, val := range keyvals {
+			c.layers.files[key] = val
+		}
+		c.keyvals = c.layers.compose()
+		new := c.keyvals
+		c.addFiles(files)
+		c.srcHash = srcHash
+		c.record()
+		c.mu.Unlock()
+		if notify {
+			c.notify(newDelta(old, new, true))
+		}
+		return nil
+	}
+	if notify {
+		old = make(map[string]any, len(keyvals))
+		for key := range keyvals {
+			if val, ok := c.keyvals[key]; ok {
+				old[key] = val
+			}
+		}
+	}
+	for key, val := range keyvals {
+		c.keyvals[key] = val
+	}
+	c.addFiles(files)
+	c.srcHash = srcHash
+	c.record()
+	c.mu.Unlock()
+	if notify {
+		c.notify(newDelta(old, keyvals, true))
+	}
+	return nil
+}
+
+// replace swaps the content of the configuration for keyvals and records the hash of their
+// source document.
+func (c *Configuration) replace(keyvals map[string]any, srcHash string) {
+	if keyvals == nil {
+		keyvals = make(map[string]any)
+	}
+	c.mu.Lock()
+	old := c.keyvals
+	c.setFileKeyvals(keyvals)
+	new := c.keyvals
+	c.srcHash = srcHash
+	c.record()
+	c.mu.Unlock()
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+}
+
+// decodeJSON decodes a JSON document into a key-value map.
+func decodeJSON(data []byte) (map[string]any, error) {
+	if AllowJSONComments {
+		data = stripJSONC(data)
+	}
+	if err := checkDepth(data); err != nil {
+		return nil, err
+	}
+	var keyvals map[string]any
+	if err := json.Unmarshal(data, &keyvals); err != nil {
+		return nil, err
+	}
+	return keyvals, nil
 }
 
-// Get retrieves a value from the configuration by key.
+// hashOf returns the hex encoded SHA-256 of data.
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get retrieves a value from the configuration by key, falling back to its default. The key may
+// be a path into nested objects, such as "server.http.port", see SetKeyDelimiter. Objects and
+// arrays are returned as deep copies, so callers may modify them freely; see GetShared.
 func (c *Configuration) Get(key string) (any, bool) {
+	val, ok := c.GetShared(key)
+	return deepCopy(val), ok
+}
+
+// GetShared is like Get but returns objects and arrays without copying them. The returned values
+// are shared with the configuration and other callers and must not be modified.
+func (c *Configuration) GetShared(key string) (any, bool) {
 	c.mu.RLock()
-	val, ok := c.keyvals[key]
+	val, ok := c.lookup(key)
 	c.mu.RUnlock()
 	return val, ok
 }
@@ -59,7 +281,7 @@ func (c *Configuration) Get(key string) (any, bool) {
 // Exists checks if a key exists in the configuration.
 func (c *Configuration) Exists(key string) bool {
 	c.mu.RLock()
-	_, ok := c.keyvals[key]
+	_, ok := findPath(c.keyvals, key, c.delimiter())
 	c.mu.RUnlock()
 	return ok
 }
@@ -67,11 +289,68 @@ func (c *Configuration) Exists(key string) bool {
 // Get retrieves a value from the configuration by key and converts it to the specified type.
 func Get[T configtype](c *Configuration, key string) T {
 	c.mu.RLock()
-	val := c.keyvals[key]
+	val, _ := c.lookup(key)
 	c.mu.RUnlock()
 	return ConvertTo[T](val)
 }
 
+// GetFirst retrieves the value of the first key in keys that exists in the configuration and
+// converts it to the specified type. It also returns the key that supplied the value and whether
+// any of the keys was found. Defaults are only used when none of the keys is set. All keys are
+// resolved under a single lock acquisition so the result can never mix values from different
+// generations of the configuration.
+func GetFirst[T configtype](c *Configuration, keys ...string) (T, string, bool) {
+	c.mu.RLock()
+	val, key, ok := c.lookupFirst(keys)
+	c.mu.RUnlock()
+	if !ok {
+		var t T
+		return t, "", false
+	}
+	return ConvertTo[T](val), key, true
+}
+
+// lookupFirst returns the value of the first key in keys that is set, falling back to the first
+// default of them, and the key that supplied the value. The caller must hold c.mu.
+func (c *Configuration) lookupFirst(keys []string) (any, string, bool) {
+	for _, key := range keys {
+		if val, ok := findPath(c.keyvals, key, c.delimiter()); ok {
+			return resolve(val), key, true
+		}
+	}
+	for _, key := range keys {
+		if val, ok := findPath(c.defaults, key, c.delimiter()); ok {
+			return val, key, true
+		}
+	}
+	return nil, "", false
+}
+
+// GetStrFirst retrieves a string value from the first existing key in keys.
+func (c *Configuration) GetStrFirst(keys ...string) (string, string, bool) {
+	return GetFirst[string](c, keys...)
+}
+
+// GetIntFirst retrieves an int value from the first existing key in keys.
+func (c *Configuration) GetIntFirst(keys ...string) (int, string, bool) {
+	return GetFirst[int](c, keys...)
+}
+
+// GetInt64First retrieves an int64 value from the first existing key in keys.
+func (c *Configuration) GetInt64First(keys ...string) (int64, string, bool) {
+	return GetFirst[int64](c, keys...)
+}
+
+// GetFloat64First retrieves a float64 value from the first existing key in keys.
+func (c *Configuration) GetFloat64First(keys ...string) (float64, string, bool) {
+	return GetFirst[float64](c, keys...)
+}
+
+// GetBoolFirst retrieves a bool value from the first existing key in keys.
+func (c *Configuration) GetBoolFirst(keys ...string) (bool, string, bool) {
+	return GetFirst[bool](c, keys...)
+}
+
 // GetStr retrieves a string value from the configuration by key.
 func (c *Configuration) GetStr(key string) string {
 	return Get[string](c, key)
@@ -97,21 +376,459 @@ func (c *Configuration) GetBool(key string) bool {
 	return Get[bool](c, key)
 }
 
-// Set sets a value in the configuration by key.
+// GetE is like Get but returns an error instead of the zero value: a *KeyError wrapping
+// ErrKeyNotFound if key is neither set nor has a default, and ErrTypeMismatch, ErrParse or
+// ErrOutOfRange if its value cannot be converted to the specified type.
+func GetE[T configtype](c *Configuration, key string) (T, error) {
+	c.mu.RLock()
+	val, ok := c.lookup(key)
+	c.mu.RUnlock()
+	if !ok {
+		var t T
+		return t, &KeyError{Key: key, Err: ErrKeyNotFound}
+	}
+	res, err := convertE[T](val)
+	if err != nil {
+		return res, &KeyError{Key: key, Err: err}
+	}
+	return res, nil
+}
+
+// GetFirstE is like GetFirst but returns an error instead of the zero value: a *KeyError
+// wrapping ErrKeyNotFound for the first key if none of the keys is found, or the conversion error
+// of the key that supplied the value, see GetE.
+func GetFirstE[T configtype](c *Configuration, keys ...string) (T, string, error) {
+	c.mu.RLock()
+	val, key, ok := c.lookupFirst(keys)
+	c.mu.RUnlock()
+	var t T
+	if !ok {
+		if len(keys) > 0 {
+			key = keys[0]
+		}
+		return t, "", &KeyError{Key: key, Err: ErrKeyNotFound}
+	}
+	res, err := convertE[T](val)
+	if err != nil {
+		return t, key, &KeyError{Key: key, Err: err}
+	}
+	return res, key, nil
+}
+
+// GetStrE retrieves a string value from the configuration by key, see GetE.
+func (c *Configuration) GetStrE(key string) (string, error) {
+	return GetE[string](c, key)
+}
+
+// GetIntE retrieves an int value from the configuration by key, see GetE.
+func (c *Configuration) GetIntE(key string) (int, error) {
+	return GetE[int](c, key)
+}
+
+// GetInt64E retrieves an int64 value from the configuration by key, see GetE.
+func (c *Configuration) GetInt64E(key string) (int64, error) {
+	return GetE[int64](c, key)
+}
+
+// GetFloat64E retrieves a float64 value from the configuration by key, see GetE.
+func (c *Configuration) GetFloat64E(key string) (float64, error) {
+	return GetE[float64](c, key)
+}
+
+// GetBoolE retrieves a bool value from the configuration by key, see GetE.
+func (c *Configuration) GetBoolE(key string) (bool, error) {
+	return GetE[bool](c, key)
+}
+
+// GetOr is like Get but returns def if key is neither set nor has a default, or if its value
+// cannot be converted to the specified type, see GetE.
+func GetOr[T configtype](c *Configuration, key string, def T) T {
+	val, err := GetE[T](c, key)
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// GetStrOr retrieves a string value from the configuration by key, or def, see GetOr.
+func (c *Configuration) GetStrOr(key string, def string) string {
+	return GetOr(c, key, def)
+}
+
+// GetIntOr retrieves an int value from the configuration by key, or def, see GetOr.
+func (c *Configuration) GetIntOr(key string, def int) int {
+	return GetOr(c, key, def)
+}
+
+// GetInt64Or retrieves an int64 value from the configuration by key, or def, see GetOr.
+func (c *Configuration) GetInt64Or(key string, def int64) int64 {
+	return GetOr(c, key, def)
+}
+
+// GetFloat64Or retrieves a float64 value from the configuration by key, or def, see GetOr.
+func (c *Configuration) GetFloat64Or(key string, def float64) float64 {
+	return GetOr(c, key, def)
+}
+
+// GetBoolOr retrieves a bool value from the configuration by key, or def, see GetOr.
+func (c *Configuration) GetBoolOr(key string, def bool) bool {
+	return GetOr(c, key, def)
+}
+
+// MustGet is like GetE but panics with the *KeyError if key is neither set nor has a default or
+// its value cannot be converted to the specified type. It is meant for required keys read at
+// startup, where failing fast is preferable to running with zero values.
+func MustGet[T configtype](c *Configuration, key string) T {
+	return must(GetE[T](c, key))
+}
+
+// MustGetStr retrieves a string value from the configuration by key, see MustGet.
+func (c *Configuration) MustGetStr(key string) string {
+	return MustGet[string](c, key)
+}
+
+// MustGetInt retrieves an int value from the configuration by key, see MustGet.
+func (c *Configuration) MustGetInt(key string) int {
+	return MustGet[int](c, key)
+}
+
+// MustGetInt64 retrieves an int64 value from the configuration by key, see MustGet.
+func (c *Configuration) MustGetInt64(key string) int64 {
+	return MustGet[int64](c, key)
+}
+
+// MustGetFloat64 retrieves a float64 value from the configuration by key, see MustGet.
+func (c *Configuration) MustGetFloat64(key string) float64 {
+	return MustGet[float64](c, key)
+}
+
+// MustGetBool retrieves a bool value from the configuration by key, see MustGet.
+func (c *Configuration) MustGetBool(key string) bool {
+	return MustGet[bool](c, key)
+}
+
+// Set sets a value in the configuration by key. The value is kept in LayerSet, so it overrides
+// the value of key in all other layers, replacing objects as a whole, and survives reloads of
+// the loaded documents. A key that is not set as a whole but is a path into an existing object
+// or array, such as "server.port" or "upstreams[2].host", sets the value inside a copy of the
+// top level value, which LayerSet then holds as a whole.
 func (c *Configuration) Set(key string, val any) {
+	if err := c.set(key, val); err != nil {
+		c.rejected(err)
+		return
+	}
+	c.checkNamespace(key)
+}
+
+// set implements Set without reporting changes of namespaces.
+func (c *Configuration) set(key string, val any) error {
+	_, err := c.setIf(key, val, nil)
+	return err
+}
+
+// SetIfEquals sets key to new like Set if its current value equals old and reports whether it
+// did, atomically, so concurrent writers can coordinate through the configuration without
+// overwriting each other's changes. Values are compared like reflect.DeepEqual, except that
+// numbers of different types are equal if they have the same value, such as 1 and 1.0. Keys that
+// are not set, even if they have a default, never equal old.
+func (c *Configuration) SetIfEquals(key string, old, new any) bool {
+	ok, err := c.setIf(key, new, func() bool {
+		cur, ok := findPath(c.keyvals, key, c.delimiter())
+		return ok && valuesEqual(resolve(cur), old)
+	})
+	if err != nil {
+		c.rejected(err)
+	}
+	if ok {
+		c.checkNamespace(key)
+	}
+	return ok
+}
+
+// SetIfAbsent sets key to val like Set if it is not set, whether or not it has a default, and
+// reports whether it did, atomically.
+func (c *Configuration) SetIfAbsent(key string, val any) bool {
+	ok, err := c.setIf(key, val, func() bool {
+		_, ok := findPath(c.keyvals, key, c.delimiter())
+		return !ok
+	})
+	if err != nil {
+		c.rejected(err)
+	}
+	if ok {
+		c.checkNamespace(key)
+	}
+	return ok
+}
+
+// setIf sets key to val if cond, which is called with c.mu held, is nil or returns true, and
+// reports whether it did. It fails if c is frozen.
+func (c *Configuration) setIf(key string, val any, cond func() bool) (bool, error) {
 	c.mu.Lock()
-	c.keyvals[key] = val
+	if err := c.checkFrozen(key); err != nil {
+		c.mu.Unlock()
+		return false, err
+	}
+	if cond != nil && !cond() {
+		c.mu.Unlock()
+		return false, nil
+	}
+	key, old, existed := c.setLocked(key, val)
+	val = c.keyvals[key]
+	c.record()
 	c.mu.Unlock()
+	if c.hasSubscribers() {
+		before := map[string]any{}
+		if existed {
+			before[key] = old
+		}
+		c.notify(newDelta(before, map[string]any{key: val}, false))
+	}
+	return true, nil
+}
+
+// valuesEqual reports whether the configuration values a and b are equal, comparing numbers by
+// value.
+func valuesEqual(a, b any) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number returns the value of the number val as a float64 and whether val is a number.
+func number(val any) (float64, bool) {
+	switch n := val.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// SetAll sets the values of all keys of keyvals like Set, in lexical order of the keys, but
+// under a single lock acquisition, so readers observe either none or all of them, and as a
+// single change notified to change callbacks.
+func (c *Configuration) SetAll(keyvals map[string]any) {
+	if err := c.setAll(keyvals, MergeShallow); err != nil {
+		c.rejected(err)
+	}
 }
 
-// Delete removes a key from the configuration.
+// setAll implements SetAll, merging the values of keyvals over the effective values of their
+// keys using strategy. It fails if c is frozen.
+func (c *Configuration) setAll(keyvals map[string]any, strategy MergeStrategy) error {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	keys := sortedKeys(keyvals)
+	before, after := make(map[string]any), make(map[string]any)
+	c.mu.Lock()
+	if err := c.checkFrozen(""); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	for _, key := range keys {
+		top, old, existed := c.setLocked(key, mergeValue(c.keyvals[key], keyvals[key], strategy))
+		if _, ok := after[top]; !ok && existed {
+			before[top] = old
+		}
+		after[top] = nil
+	}
+	for top := range after {
+		after[top] = c.keyvals[top]
+	}
+	c.record()
+	c.mu.Unlock()
+	if c.hasSubscribers() {
+		c.notify(newDelta(before, after, false))
+	}
+	for _, key := range keys {
+		c.checkNamespace(key)
+	}
+	return nil
+}
+
+// setLocked sets key to val in LayerSet and returns the top level key holding the value together
+// with its previous value and whether it existed. The caller must hold c.mu.
+func (c *Configuration) setLocked(key string, val any) (string, any, bool) {
+	ls := c.useLayers()
+	if top, v, ok := c.setNested(key, val); ok {
+		key, val = top, v
+	}
+	old, existed := c.keyvals[key]
+	ls.set[key] = val
+	c.keyvals[key] = val
+	return key, old, existed
+}
+
+// setNested returns a copy of the top level value of the path key with val set at the rest of
+// the path, if key is not set as a whole and names a value inside an existing object or array,
+// together with the top level key. The caller must hold c.mu.
+func (c *Configuration) setNested(key string, val any) (string, any, bool) {
+	if _, ok := c.keyvals[key]; ok {
+		return "", nil, false
+	}
+	delim := c.delimiter()
+	path := indexPath(key, delim)
+	for i := strings.Index(path, delim); i >= 0; {
+		if cur, ok := c.keyvals[path[:i]]; ok && isComposite(resolve(cur)) {
+			top := deepCopy(resolve(cur))
+			if !setIn(top, path[i+len(delim):], delim, val) {
+				return "", nil, false
+			}
+			return path[:i], top, true
+		}
+		j := strings.Index(path[i+len(delim):], delim)
+		if j < 0 {
+			break
+		}
+		i += len(delim) + j
+	}
+	return "", nil, false
+}
+
+// removeNested removes the path key from copies of the top level values holding it in the set
+// and files layers, if key is not set as a whole and names a value inside an object, and returns
+// the top level key together with its previous effective value. The caller must hold c.mu.
+func (c *Configuration) removeNested(key string) (string, any, bool) {
+	if _, ok := c.keyvals[key]; ok {
+		return "", nil, false
+	}
+	delim := c.delimiter()
+	path := indexPath(key, delim)
+	for i := strings.Index(path, delim); i >= 0; {
+		top, rest := path[:i], path[i+len(delim):]
+		if old, ok := c.keyvals[top]; ok && isComposite(resolve(old)) {
+			ms := []map[string]any{c.keyvals}
+			if c.layers != nil {
+				ms = []map[string]any{c.layers.set, c.layers.files}
+			}
+			removed := false
+			for _, m := range ms {
+				if val, ok := m[top]; ok {
+					val = deepCopy(resolve(val))
+					if deleteIn(val, rest, delim) {
+						m[top] = val
+						removed = true
+					}
+				}
+			}
+			if removed {
+				if c.layers != nil {
+					c.keyvals = c.layers.compose()
+				}
+				return top, old, true
+			}
+		}
+		j := strings.Index(path[i+len(delim):], delim)
+		if j < 0 {
+			break
+		}
+		i += len(delim) + j
+	}
+	return "", nil, false
+}
+
+// Delete removes a key from the configuration by removing it from LayerSet and LayerFiles. Values
+// of key in the env and flags layers and defaults remain visible. A key that is not set as a
+// whole but is a path into an object, such as "server.port", is removed from copies of the top
+// level values holding it.
 func (c *Configuration) Delete(key string) {
+	if err := c.delete(key); err != nil {
+		c.rejected(err)
+		return
+	}
+	c.checkNamespace(key)
+}
+
+// delete implements Delete without reporting changes of namespaces. It fails if c is frozen.
+func (c *Configuration) delete(key string) error {
 	c.mu.Lock()
-	delete(c.keyvals, key)
+	if err := c.checkFrozen(key); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	var old, val any
+	var existed, exists bool
+	if top, before, ok := c.removeNested(key); ok {
+		key, old, existed = top, before, true
+		val, exists = c.keyvals[key]
+	} else {
+		old, existed = c.keyvals[key]
+		val, exists = c.removeKey(key)
+	}
+	if existed {
+		c.record()
+	}
 	c.mu.Unlock()
+	if existed && c.hasSubscribers() {
+		after := map[string]any{}
+		if exists {
+			after[key] = val
+		}
+		c.notify(newDelta(map[string]any{key: old}, after, false))
+	}
+	return nil
 }
 
-// ConvertTo converts a value to the specified type.
+// Clear removes all keys from the configuration, from all layers, and forgets the files it was
+// read from, so the next load starts from scratch and Reload fails until a file is read. Defaults,
+// descriptions, bindings, the selected profile and change callbacks are kept; the removed keys
+// are notified to change callbacks. Bound environment variables and flags reappear with ReloadEnv
+// and ReloadFlags.
+func (c *Configuration) Clear() {
+	c.mu.Lock()
+	if err := c.checkFrozen(""); err != nil {
+		c.mu.Unlock()
+		c.rejected(err)
+		return
+	}
+	old := c.keyvals
+	c.keyvals = make(map[string]any)
+	if c.layers != nil {
+		c.layers = &layers{files: c.keyvals, set: make(map[string]any)}
+		c.keyvals = c.layers.compose()
+	}
+	c.profileBase, c.profiles = nil, nil
+	c.files = nil
+	c.srcHash = ""
+	c.record()
+	c.mu.Unlock()
+	if len(old) > 0 && c.hasSubscribers() {
+		c.notify(newDelta(old, map[string]any{}, false))
+	}
+}
+
+// Len returns the number of top level keys in the effective configuration, not counting keys
+// that only have a default.
+func (c *Configuration) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.keyvals)
+}
+
+// checkNamespace calls the namespace hook if key is inside a registered namespace.
+func (c *Configuration) checkNamespace(key string) {
+	c.mu.RLock()
+	hook, ns := c.nsHook, ""
+	if hook != nil {
+		ns = c.namespaceOf(key)
+	}
+	c.mu.RUnlock()
+	if ns != "" {
+		hook(key, ns)
+	}
+}
+
+// ConvertTo converts a value to the specified type. Bools are converted to the strings "true"
+// and "false".
 func ConvertTo[T configtype](val any) T {
 
 	// type already matches
@@ -212,10 +929,7 @@ func ConvertTo[T configtype](val any) T {
 	case bool:
 		switch any(t).(type) {
 		case string:
-			if v {
-				return any(v).(T)
-			}
-			return any(false).(T)
+			return any(strconv.FormatBool(v)).(T)
 		case int:
 			if v {
 				return any(int(1)).(T)
@@ -0,0 +1,389 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentAccess(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"server": {"port": 8080, "tags": ["a", "b"]}}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 200
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				fn(i)
+			}
+		}()
+	}
+	run(func(i int) { c.Set("n", i) })
+	run(func(int) {
+		if err := c.Reload(); err != nil {
+			t.Error(err)
+		}
+	})
+	run(func(i int) {
+		// values returned by Get are copies the caller may modify
+		val, _ := c.Get("server")
+		server := val.(map[string]any)
+		server["port"] = i
+		server["tags"].([]any)[0] = "x"
+	})
+	run(func(int) {
+		if port := c.GetInt("server.port"); port != 8080 {
+			t.Errorf("got port %d, want 8080", port)
+		}
+		if tag := c.GetStr("server.tags.0"); tag != "a" {
+			t.Errorf("got tag %q, want a", tag)
+		}
+		c.GetInt("n")
+		c.AllSettings()
+	})
+	wg.Wait()
+}
+
+func TestReadFileE(t *testing.T) {
+	dir := t.TempDir()
+	c, err := ReadFileE(filepath.Join(dir, "missing.json"))
+	if !errors.Is(err, fs.ErrNotExist) || c != nil {
+		t.Fatalf("got %v, %v, want a nil configuration and fs.ErrNotExist", c, err)
+	}
+	if _, err := ReadFileE(writeFile(t, dir, "broken.json", `{`)); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Fatalf("got %v, want an error naming the file", err)
+	}
+	c, err = ReadFileE(writeFile(t, dir, "app.json", `{"readfilee_test": 1}`))
+	if err != nil || c != Config() {
+		t.Fatalf("got %v, %v, want the global configuration", c, err)
+	}
+	if got := c.GetInt("readfilee_test"); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ReadFile did not panic for a missing file")
+		}
+	}()
+	ReadFile(filepath.Join(dir, "missing.json"))
+}
+
+func TestGetFirstE(t *testing.T) {
+	c := New()
+	c.Set("new", map[string]any{"port": "http"})
+	c.Set("old", map[string]any{"port": 80})
+	c.SetDefault("legacy.port", 1)
+
+	for _, tt := range []struct {
+		keys    []string
+		want    int
+		wantKey string
+		err     error
+	}{
+		{[]string{"missing.port", "old.port"}, 80, "old.port", nil},
+		{[]string{"new.port", "old.port"}, 0, "new.port", ErrParse},
+		{[]string{"missing.port", "legacy.port"}, 1, "legacy.port", nil},
+		{[]string{"missing.port", "other.port"}, 0, "", ErrKeyNotFound},
+	} {
+		got, key, err := GetFirstE[int](c, tt.keys...)
+		if got != tt.want || key != tt.wantKey || !errors.Is(err, tt.err) {
+			t.Errorf("%v: got %d, %q, %v, want %d, %q, %v", tt.keys, got, key, err, tt.want, tt.wantKey, tt.err)
+		}
+	}
+	_, _, err := GetFirstE[int](c, "missing.port", "other.port")
+	var kerr *KeyError
+	if !errors.As(err, &kerr) || kerr.Key != "missing.port" {
+		t.Errorf("got %v, want a *KeyError for the first key", err)
+	}
+	if _, _, err := GetFirstE[int](c); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("no keys: got %v", err)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	c := New()
+	c.Set("port", "8080")
+	c.Set("word", "abc")
+	c.Set("zero", 0)
+	c.SetDefault("timeout", 30)
+
+	if got := c.GetIntOr("port", 1); got != 8080 {
+		t.Errorf("port: got %d", got)
+	}
+	if got := c.GetIntOr("zero", 1); got != 0 {
+		t.Errorf("zero: got %d, want the set zero value", got)
+	}
+	if got := c.GetIntOr("timeout", 1); got != 30 {
+		t.Errorf("timeout: got %d, want the registered default", got)
+	}
+	if got := c.GetIntOr("missing", 1); got != 1 {
+		t.Errorf("missing: got %d, want the fallback", got)
+	}
+	if got := c.GetIntOr("word", 1); got != 1 {
+		t.Errorf("word: got %d, want the fallback for an unparsable value", got)
+	}
+	if got := c.GetStrOr("missing", "x") + c.GetStrOr("word", "x"); got != "xabc" {
+		t.Errorf("strings: got %q", got)
+	}
+	if !c.GetBoolOr("missing", true) || c.GetFloat64Or("port", 0) != 8080 || c.GetInt64Or("missing", 7) != 7 {
+		t.Error("typed variants do not match GetOr")
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	c := New()
+	c.Set("port", 8080)
+	c.Set("word", "abc")
+	if got := c.MustGetInt("port"); got != 8080 {
+		t.Errorf("got %d", got)
+	}
+	if got := MustGet[string](c, "port"); got != "8080" {
+		t.Errorf("got %q", got)
+	}
+
+	for key, want := range map[string]error{"missing": ErrKeyNotFound, "word": ErrParse} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				var kerr *KeyError
+				if !errors.Is(err, want) || !errors.As(err, &kerr) || kerr.Key != key {
+					t.Errorf("%s: panicked with %v, want a *KeyError wrapping %v", key, err, want)
+				}
+			}()
+			c.MustGetInt(key)
+		}()
+	}
+}
+
+func TestNewIsIndependent(t *testing.T) {
+	dir := t.TempDir()
+	a, b := New(), New()
+	if err := a.ReadFile(writeFile(t, dir, "a.json", `{"new_test": "a"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ReadFiles(writeFile(t, dir, "b.json", `{"new_test": "b"}`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Set("only_b", true)
+	b.SetDefault("only_b_default", 1)
+
+	if got := a.GetStr("new_test") + b.GetStr("new_test"); got != "ab" {
+		t.Errorf("got %q, want each configuration to keep its own file", got)
+	}
+	if a.Exists("only_b") || a.GetInt("only_b_default") != 0 {
+		t.Error("a sees the values of b")
+	}
+	if Config().Exists("new_test") || Config().Exists("only_b") {
+		t.Error("the global configuration sees the values of independent ones")
+	}
+	if srcs := a.Sources(); len(srcs) != 1 || filepath.Base(srcs[0]) != "a.json" {
+		t.Errorf("a was read from %v", srcs)
+	}
+
+	// reloading one configuration does not touch the other
+	writeFile(t, dir, "a.json", `{"new_test": "a2"}`)
+	if err := a.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetStr("new_test") + b.GetStr("new_test"); got != "a2b" {
+		t.Errorf("after reloading a: got %q", got)
+	}
+}
+
+func TestClearAndLen(t *testing.T) {
+	dir := t.TempDir()
+	c := New()
+	if err := c.ReadFile(writeFile(t, dir, "app.json", `{"port": 80, "server": {"host": "x"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("name", "app")
+	c.SetDefault("timeout", 5)
+	if got := c.Len(); got != 3 {
+		t.Errorf("got Len %d, want the top level keys without defaults", got)
+	}
+
+	var removed []any
+	c.OnChange("port", func(old, new any) error {
+		removed = append(removed, old, new)
+		return nil
+	})
+	c.Clear()
+	if got := c.Len(); got != 0 {
+		t.Errorf("after Clear: got Len %d", got)
+	}
+	if c.Exists("name") || c.Exists("server.host") {
+		t.Error("keys survived Clear")
+	}
+	if got := c.GetInt("timeout"); got != 5 {
+		t.Errorf("got timeout %d, want Clear to keep the defaults", got)
+	}
+	if len(removed) != 2 || removed[1] != nil {
+		t.Errorf("got changes %v, want port removed", removed)
+	}
+	if err := c.Reload(); err == nil {
+		t.Error("reloaded after Clear forgot the files")
+	}
+	if err := c.ReadFile(writeFile(t, dir, "other.json", `{"other": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Len(); got != 1 {
+		t.Errorf("after reading again: got Len %d", got)
+	}
+}
+
+func TestClone(t *testing.T) {
+	dir := t.TempDir()
+	fname := writeFile(t, dir, "app.json", `{"server": {"hosts": ["a", "b"], "port": 80}}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("tenant", map[string]any{"name": "shared"})
+	c.SetDefault("timeout", 5)
+	called := false
+	c.OnChange("server.port", func(old, new any) error {
+		called = true
+		return nil
+	})
+
+	n := c.Clone()
+	n.Set("server.port", 8080)
+	n.Set("server.hosts[0]", "changed")
+	n.Set("tenant.name", "private")
+	if got := c.GetInt("server.port"); got != 80 {
+		t.Errorf("changing the clone changed server.port of c to %d", got)
+	}
+	if got := c.GetStr("server.hosts.0") + c.GetStr("tenant.name"); got != "ashared" {
+		t.Errorf("the clone shares values with c: got %q", got)
+	}
+	if called {
+		t.Error("the clone notified the callbacks of c")
+	}
+	if got := n.GetInt("timeout"); got != 5 {
+		t.Errorf("got timeout %d, want the clone to keep the defaults", got)
+	}
+
+	writeFile(t, dir, "app.json", `{"server": {"port": 81}}`)
+	if err := n.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.GetInt("server.port"); got != 8080 {
+		t.Errorf("got %d, want the value set on the clone to survive its reload", got)
+	}
+	if got := n.GetStr("tenant.name"); got != "private" {
+		t.Errorf("got tenant %q after reloading the clone", got)
+	}
+	if got := c.GetStr("server.hosts.1"); got != "b" {
+		t.Errorf("reloading the clone reloaded c: got %q", got)
+	}
+
+	c.Freeze()
+	if n := c.Clone(); n.Frozen() {
+		t.Error("the clone of a frozen configuration is frozen")
+	}
+}
+
+func TestSetAll(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"server": {"host": "a", "port": 1}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.OnReload(func([]Change) { t.Error("SetAll notified a reload") })
+	calls := map[string]int{}
+	for _, key := range []string{"server", "name"} {
+		c.OnChange(key, func(old, new any) error {
+			calls[key]++
+			return nil
+		})
+	}
+	version := c.Version()
+	c.SetAll(map[string]any{"server.port": 2, "server.tls": true, "name": "app"})
+	if got := fmt.Sprintf("%s %d %t %s", c.GetStr("server.host"), c.GetInt("server.port"), c.GetBool("server.tls"), c.GetStr("name")); got != "a 2 true app" {
+		t.Errorf("got %q", got)
+	}
+	if c.Version() != version+1 {
+		t.Errorf("got %d versions, want a single one", c.Version()-version)
+	}
+	if calls["server"] != 1 || calls["name"] != 1 {
+		t.Errorf("got notifications %v, want one for each key", calls)
+	}
+	c.SetAll(nil)
+	if c.Version() != version+1 {
+		t.Error("SetAll without values made a version")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			c.SetAll(map[string]any{"a": i, "b": i})
+		}
+	}()
+	for range 100 {
+		if all := c.AllSettings(); all["a"] != all["b"] {
+			t.Fatalf("observed some of the values of SetAll: %v", all)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSetIf(t *testing.T) {
+	c := New()
+	c.SetDefault("mode", "default")
+	if c.SetIfEquals("mode", "default", "x") {
+		t.Error("SetIfEquals matched the default of an unset key")
+	}
+	if !c.SetIfAbsent("mode", "first") || c.SetIfAbsent("mode", "second") {
+		t.Error("SetIfAbsent set a key that is set or did not set an absent one")
+	}
+	if got := c.GetStr("mode"); got != "first" {
+		t.Errorf("got mode %q", got)
+	}
+	if c.SetIfEquals("mode", "other", "x") || !c.SetIfEquals("mode", "first", "second") {
+		t.Error("SetIfEquals compared the wrong value")
+	}
+
+	if err := c.LoadBytes([]byte(`{"server": {"port": 80}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !c.SetIfEquals("server.port", 80, 81) {
+		t.Error("SetIfEquals did not compare the int with the float of the document")
+	}
+	if c.SetIfAbsent("server.port", 1) || !c.SetIfAbsent("server.host", "x") {
+		t.Error("SetIfAbsent misjudged nested keys")
+	}
+
+	// concurrent increments do not lose updates
+	c.Set("n", 0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				for {
+					n := c.GetInt("n")
+					if c.SetIfEquals("n", n, n+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.GetInt("n"); got != 400 {
+		t.Errorf("got n %d, want 400", got)
+	}
+}
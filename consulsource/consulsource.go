@@ -0,0 +1,103 @@
+// Package consulsource provides a configuration provider reading the keys below a prefix of the
+// Consul KV store and watching them for changes with blocking queries:
+//
+//	src := consulsource.New(client, "myapp/")
+//	err := config.Config().StartWatch(ctx, src)
+//
+// The keys below the prefix are split at "/" into nested configuration keys, so the Consul key
+// "myapp/db/host" sets "host" inside the object "db". Values are decoded with
+// config.ParseValue. Keys ending in "/" are folders and are ignored.
+package consulsource
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// WaitTime is the maximum duration a blocking query waits for a change before Consul answers
+// with the unchanged keys.
+var WaitTime = 5 * time.Minute
+
+// Source is a config.Provider reading the keys below a prefix of the Consul KV store.
+type Source struct {
+	client *api.Client
+	prefix string
+
+	mu    sync.Mutex
+	index uint64 // Consul index of the last read
+}
+
+// New returns a Source reading the keys below prefix using client.
+func New(client *api.Client, prefix string) *Source {
+	return &Source{client: client, prefix: prefix}
+}
+
+// Name identifies the source by its prefix.
+func (s *Source) Name() string {
+	return "consul:" + s.prefix
+}
+
+// Load reads the current values of the keys below the prefix.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	doc, _, err := s.list(ctx, 0)
+	return doc, err
+}
+
+// Watch reports the document whenever a blocking query returns after a change of the keys below
+// the prefix, starting after the index read by the last Load.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	for {
+		s.mu.Lock()
+		index := s.index
+		s.mu.Unlock()
+
+		doc, changed, err := s.list(ctx, index)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if changed {
+			fn(doc)
+		}
+	}
+}
+
+// list reads the keys below the prefix, blocking until the Consul index differs from index if it
+// is not 0, and reports whether the index changed.
+func (s *Source) list(ctx context.Context, index uint64) (map[string]any, bool, error) {
+	opts := (&api.QueryOptions{WaitIndex: index, WaitTime: WaitTime}).WithContext(ctx)
+	pairs, meta, err := s.client.KV().List(s.prefix, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	kvs := make(map[string]any, len(pairs))
+	for _, pair := range pairs {
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		kvs[strings.Trim(strings.TrimPrefix(pair.Key, s.prefix), "/")] = config.ParseValue(pair.Value)
+	}
+	doc, err := config.Nest(kvs, "/")
+	if err != nil {
+		return nil, false, err
+	}
+
+	// an index going backwards means the KV store was reset, so the next query must not block
+	// on the stale index
+	next := meta.LastIndex
+	if next < index {
+		next = 0
+	}
+	s.mu.Lock()
+	s.index = next
+	s.mu.Unlock()
+	return doc, meta.LastIndex != index, nil
+}
@@ -0,0 +1,45 @@
+// Package cueformat registers a decoder for CUE configuration files with the config package.
+// Import it for its side effect:
+//
+//	import _ "github.com/Carl-Frankenfeld/config/cueformat"
+//
+// The decoder lives in its own package so programs not reading CUE files do not depend on the
+// CUE evaluator.
+package cueformat
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+func init() {
+	config.RegisterFormat(".cue", decodeCUE, nil)
+}
+
+// decodeCUE evaluates a CUE document and decodes the result into a key-value map. The document
+// must evaluate to a struct of concrete values satisfying all constraints it declares, so
+// definitions and constraints such as `port: int & >0 & <65536` validate the configuration
+// before it is applied. Violations are reported with their positions. CUE documents can only be
+// read.
+func decodeCUE(data []byte) (map[string]any, error) {
+	val := cuecontext.New().CompileBytes(data, cue.Filename("config.cue"))
+	if err := val.Validate(cue.Concrete(true)); err != nil {
+		return nil, cueError(err)
+	}
+	var keyvals map[string]any
+	if err := val.Decode(&keyvals); err != nil {
+		return nil, cueError(err)
+	}
+	if keyvals == nil {
+		keyvals = make(map[string]any)
+	}
+	return keyvals, nil
+}
+
+// cueError converts err into an error describing all CUE errors it holds.
+func cueError(err error) error {
+	return errors.New("config: invalid CUE document:\n" + errors.Details(err, nil))
+}
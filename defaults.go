@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetDefault registers the default value of key, which is returned by the getters while the key
+// is not set in the configuration.
+func (c *Configuration) SetDefault(key string, val any) {
+	c.mu.Lock()
+	if c.defaults == nil {
+		c.defaults = make(map[string]any)
+	}
+	c.defaults[key] = val
+	c.mu.Unlock()
+}
+
+// Describe registers a human readable description of key for generated examples and
+// documentation.
+func (c *Configuration) Describe(key, text string) {
+	c.mu.Lock()
+	if c.descs == nil {
+		c.descs = make(map[string]string)
+	}
+	c.descs[key] = text
+	c.mu.Unlock()
+}
+
+// Require declares keys as required settings that must be provided, such as credentials without a
+// sensible default. Usage marks them as required. Like keys with a description they are
+// declared, so they are documented and not reported as unknown by ValidateFile.
+func (c *Configuration) Require(keys ...string) {
+	c.mu.Lock()
+	if c.required == nil {
+		c.required = make(map[string]bool)
+	}
+	for _, key := range keys {
+		c.required[key] = true
+	}
+	c.mu.Unlock()
+}
+
+// lookup returns the value of key, falling back to its default. The caller must hold c.mu.
+func (c *Configuration) lookup(key string) (any, bool) {
+	if val, ok := findPath(c.keyvals, key, c.delimiter()); ok {
+		return resolve(val), true
+	}
+	return findPath(c.defaults, key, c.delimiter())
+}
+
+// declaredKeys returns the keys that have a default or a description or are required. The caller
+// must hold c.mu.
+func (c *Configuration) declaredKeys() map[string]bool {
+	keys := make(map[string]bool, len(c.defaults)+len(c.descs))
+	for key := range c.defaults {
+		keys[key] = true
+	}
+	for key := range c.descs {
+		keys[key] = true
+	}
+	for key := range c.required {
+		keys[key] = true
+	}
+	return keys
+}
+
+// undeclaredKeys returns the sorted key paths of the values set in the configuration that are
+// neither declared nor nested inside a declared key, such as "db.user" for {"db": {"user": "x"}}
+// if neither "db.user" nor "db" is declared. The caller must hold c.mu.
+func (c *Configuration) undeclaredKeys(declared map[string]bool) []string {
+	delim := c.delimiter()
+	var keys []string
+next:
+	for key := range Flatten(c.keyvals, delim) {
+		for path := key; ; {
+			if declared[path] {
+				continue next
+			}
+			i := strings.LastIndex(path, delim)
+			if i < 0 {
+				break
+			}
+			path = path[:i]
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// typeName returns the name of the type of a configuration value as used in documentation.
+func typeName(val any) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case int, int64:
+		return "integer"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
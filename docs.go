@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GenerateDocs writes Markdown documentation of the configuration to w: a table of every key with
+// a default or a description, listing its type, default and description, followed by a list of
+// the undocumented values that are set in the configuration without being declared, such as
+// "db.user" if neither it nor "db" is declared. Keys are sorted so the output is stable.
+func (c *Configuration) GenerateDocs(w io.Writer) error {
+	c.mu.RLock()
+	declared := c.declaredKeys()
+	keys := sortedKeys(declared)
+	rows := make([][3]string, len(keys))
+	for i, key := range keys {
+		val, hasDefault := c.defaults[key]
+		if !hasDefault {
+			val, _ = findPath(c.keyvals, key, c.delimiter())
+			val = resolve(val)
+		}
+		def := ""
+		if hasDefault {
+			def = "`" + markdownJSON(val) + "`"
+		}
+		rows[i] = [3]string{typeName(val), def, c.descs[key]}
+	}
+	undocumented := c.undeclaredKeys(declared)
+	c.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Configuration")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "| Key | Type | Default | Description |")
+	fmt.Fprintln(bw, "| --- | --- | --- | --- |")
+	for i, key := range keys {
+		fmt.Fprintf(bw, "| `%s` | %s | %s | %s |\n", key, rows[i][0], rows[i][1], markdownCell(rows[i][2]))
+	}
+	if len(undocumented) > 0 {
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "## Undocumented keys")
+		fmt.Fprintln(bw)
+		for _, key := range undocumented {
+			fmt.Fprintf(bw, "- `%s`\n", key)
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownJSON renders val as JSON for use in a Markdown code span inside a table cell.
+func markdownJSON(val any) string {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return markdownCell(fmt.Sprint(val))
+	}
+	return markdownCell(string(data))
+}
+
+// markdownCell escapes text for use in a single Markdown table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
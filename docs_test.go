@@ -0,0 +1,34 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDocsNestedKeys(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{
+		"server": {"port": 8080, "debug": true},
+		"db": {"user": "app"},
+		"name": "demo"
+	}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	c.Describe("server.port", "port to listen on")
+	c.SetDefault("db", map[string]any{})
+
+	var buf bytes.Buffer
+	if err := c.GenerateDocs(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "| `server.port` | number |  | port to listen on |") {
+		t.Errorf("server.port is not documented with the type of its value:\n%s", out)
+	}
+	_, undocumented, _ := strings.Cut(out, "## Undocumented keys")
+	if want := "\n\n- `name`\n- `server.debug`\n"; undocumented != want {
+		t.Errorf("got undocumented keys %q, want %q", undocumented, want)
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterFormat(".env", decodeDotEnv, nil)
+}
+
+// ReadDotEnv is like Configuration.ReadDotEnv for the global configuration.
+func ReadDotEnv(fname string) error {
+	return config.ReadDotEnv(fname)
+}
+
+// ReadDotEnv reads a dotenv file of KEY=VALUE lines and updates c with its variables, whatever the
+// name of the file. Files named "*.env" can be read with ReadFile as well. Reload and WatchFile
+// re-read the file as a dotenv file.
+//
+// Lines may start with "export ", blank lines and lines starting with "#" are ignored. Values are
+// trimmed and may be enclosed in single quotes, taken literally, or double quotes, in which the
+// escape sequences \n, \r, \t, \", \\ and \$ are interpreted and which may span lines. Unquoted
+// values end at a "#" preceded by whitespace, which starts a comment. All values are strings.
+func (c *Configuration) ReadDotEnv(fname string) error {
+	data, err := readFile(fname)
+	if err != nil {
+		return err
+	}
+	keyvals, err := decodeDotEnv(data)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", fname, err)
+	}
+	return c.merge(keyvals, []fileSource{{name: fname, present: true, format: ".env"}}, hashOf(data))
+}
+
+// decodeDotEnv decodes a dotenv document into a key-value map.
+func decodeDotEnv(data []byte) (map[string]any, error) {
+	keyvals := make(map[string]any)
+	rest := strings.ReplaceAll(string(data), "\r\n", "\n")
+	for n := 1; rest != ""; n++ {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		val = strings.TrimLeft(val, " \t")
+
+		switch {
+		case strings.HasPrefix(val, "'"):
+			end := strings.IndexByte(val[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quoted value", n)
+			}
+			keyvals[key] = val[1 : end+1]
+		case strings.HasPrefix(val, `"`):
+			// the value may continue on the following lines
+			s, remaining, lines, err := unquoteDouble(val[1:] + "\n" + rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			keyvals[key] = s
+			rest = remaining
+			n += lines
+		default:
+			if i := strings.Index(val, " #"); i >= 0 {
+				val = val[:i]
+			}
+			if i := strings.Index(val, "\t#"); i >= 0 {
+				val = val[:i]
+			}
+			keyvals[key] = strings.TrimSpace(val)
+		}
+	}
+	return keyvals, nil
+}
+
+// unquoteDouble decodes the double quoted value at the start of s, whose opening quote was
+// removed. It returns the value, the input following the line of the closing quote and the
+// number of line breaks inside the value.
+func unquoteDouble(s string) (string, string, int, error) {
+	var b strings.Builder
+	lines := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			_, rest, _ := strings.Cut(s[i+1:], "\n")
+			return b.String(), rest, lines, nil
+		case '\\':
+			if i+1 == len(s) {
+				break
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			if c == '\n' {
+				lines++
+			}
+			b.WriteByte(c)
+		}
+	}
+	return "", "", 0, fmt.Errorf("unterminated double quoted value")
+}
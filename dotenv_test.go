@@ -0,0 +1,71 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDotEnv(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      map[string]any
+	}{
+		{"plain", "A=1\nB = two words \n", map[string]any{"A": "1", "B": "two words"}},
+		{"export and comments", "# comment\n\nexport A=1 # trailing\nB=x#y\nC=z\t# tab\n", map[string]any{"A": "1", "B": "x#y", "C": "z"}},
+		{"single quotes", `A='$x \n # y'` + "\n", map[string]any{"A": `$x \n # y`}},
+		{"double quotes", `A="a\tb\n\"c\" \\ \$d \q"` + "\n", map[string]any{"A": "a\tb\n\"c\" \\ $d \\q"}},
+		{"multi-line", "A=\"first\nsecond\"\nB=1\n", map[string]any{"A": "first\nsecond", "B": "1"}},
+		{"empty values", "A=\nB=''\nC=\"\"\n", map[string]any{"A": "", "B": "", "C": ""}},
+		{"duplicate keys", "A=1\nA=2\n", map[string]any{"A": "2"}},
+		{"windows line endings", "A=1\r\nB=\"x\r\ny\"\r\n", map[string]any{"A": "1", "B": "x\ny"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDotEnv([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDotEnvErrors(t *testing.T) {
+	for _, tt := range []struct{ doc, err string }{
+		{"A\n", "line 1: expected KEY=VALUE"},
+		{"=1\n", "line 1: expected KEY=VALUE"},
+		{"MY KEY=1\n", "line 1: expected KEY=VALUE"},
+		{"A='open\n", "line 1: unterminated single quoted value"},
+		{"A=\"open\nB=1\n", "line 1: unterminated double quoted value"},
+		{"A=\"x\ny\"\nbroken\n", "line 3: expected KEY=VALUE"},
+	} {
+		if _, err := decodeDotEnv([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+}
+
+func TestReadDotEnv(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "settings", "export TOKEN=abc\n")
+	c := New()
+	if err := c.ReadDotEnv(fname); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("TOKEN"); got != "abc" {
+		t.Fatalf("got %q, want abc", got)
+	}
+	writeFile(t, filepath.Dir(fname), "settings", "TOKEN='def'\n")
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("TOKEN"); got != "def" {
+		t.Fatalf("after reload: got %q, want def", got)
+	}
+	if err := c.ReadDotEnv(fname + ".missing"); err == nil {
+		t.Fatal("read a missing file")
+	}
+}
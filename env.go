@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvOption configures how AutomaticEnv maps environment variables to keys.
+type EnvOption func(*autoEnv)
+
+// autoEnv holds the settings of AutomaticEnv.
+type autoEnv struct {
+	prefix string // variable name prefix including its trailing underscore
+	sep    string // separator of nested keys in variable names
+}
+
+// WithEnvSeparator sets the separator of nested keys in variable names, "_" by default. With
+// "__", the variable MYAPP_LOG__FILE_NAME maps to the key "file_name" inside the object "log".
+func WithEnvSeparator(sep string) EnvOption {
+	return func(a *autoEnv) {
+		a.sep = sep
+	}
+}
+
+// AutomaticEnv maps every environment variable whose name starts with prefix followed by an
+// underscore to a key of the env layer, which overrides the loaded documents; see Layer. The rest
+// of the name is lower-cased and split at the separator into nested keys, so with the prefix
+// "MYAPP" the variable MYAPP_SERVER_PORT sets "port" inside the object "server". Values are
+// strings, which the typed getters convert as needed. An empty prefix maps all variables.
+//
+// The variables are read when AutomaticEnv is called and again by ReloadEnv. AutomaticEnv fails
+// if a variable maps to a key that another variable maps to an object, such as MYAPP_DB next to
+// MYAPP_DB_HOST.
+func (c *Configuration) AutomaticEnv(prefix string, opts ...EnvOption) error {
+	a := &autoEnv{sep: "_"}
+	if prefix != "" {
+		a.prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_")) + "_"
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.sep == "" {
+		return fmt.Errorf("config: empty environment variable separator")
+	}
+	c.mu.Lock()
+	c.autoEnv = a
+	c.mu.Unlock()
+	return c.ReloadEnv()
+}
+
+// BindEnv binds key, which may name a nested key such as "db.url", to the environment variables
+// envVars in order of priority: the value of the first variable that is set goes to the env
+// layer, overriding any variable mapped to key by AutomaticEnv. Binding a key again replaces its
+// variables. The variables are read when BindEnv is called and again by ReloadEnv.
+func (c *Configuration) BindEnv(key string, envVars ...string) error {
+	if key == "" {
+		return fmt.Errorf("config: empty key")
+	}
+	if len(envVars) == 0 {
+		return fmt.Errorf("config: no environment variables to bind to key %q", key)
+	}
+	c.mu.Lock()
+	if c.envBinds == nil {
+		c.envBinds = make(map[string][]string)
+	}
+	c.envBinds[key] = slices.Clone(envVars)
+	c.mu.Unlock()
+	return c.ReloadEnv()
+}
+
+// ReloadEnv reads the environment variables mapped by AutomaticEnv and bound by BindEnv again
+// and replaces the env layer with their values, notifying the changes like a reload.
+func (c *Configuration) ReloadEnv() error {
+	c.mu.RLock()
+	a := c.autoEnv
+	binds := maps.Clone(c.envBinds)
+	c.mu.RUnlock()
+	keyvals, err := envKeyvals(os.Environ(), a, binds)
+	if err != nil {
+		return err
+	}
+	return c.SetLayer(LayerEnv, keyvals)
+}
+
+// envVars returns the environment variables overriding key in order of priority: the variables
+// bound to it with BindEnv followed by the variable AutomaticEnv maps to it, if any. The caller
+// must hold c.mu.
+func (c *Configuration) envVars(key string) []string {
+	vars := slices.Clone(c.envBinds[key])
+	a := c.autoEnv
+	if a == nil {
+		return vars
+	}
+	parts := strings.Split(key, c.delimiter())
+	for _, part := range parts {
+		// AutomaticEnv lower-cases names and splits them at the separator
+		if part == "" || part != strings.ToLower(part) || strings.Contains(part, a.sep) || strings.Contains(part, ".") {
+			return vars
+		}
+	}
+	return append(vars, a.prefix+strings.ToUpper(strings.Join(parts, a.sep)))
+}
+
+// envKeyvals returns the values of the variables in environ, given as NAME=VALUE, mapped as
+// configured by a, which may be nil, and by the bindings binds.
+func envKeyvals(environ []string, a *autoEnv, binds map[string][]string) (map[string]any, error) {
+	flat := make(map[string]any)
+	if a != nil {
+		for _, kv := range environ {
+			name, val, _ := strings.Cut(kv, "=")
+			rest, ok := strings.CutPrefix(strings.ToUpper(name), a.prefix)
+			if !ok || rest == "" {
+				continue
+			}
+			parts := strings.Split(strings.ToLower(rest), a.sep)
+			if slices.Contains(parts, "") || strings.Contains(rest, ".") {
+				continue
+			}
+			flat[strings.Join(parts, ".")] = val
+		}
+	}
+	if len(binds) > 0 {
+		vars := make(map[string]string, len(environ))
+		for _, kv := range environ {
+			name, val, _ := strings.Cut(kv, "=")
+			vars[name] = val
+		}
+		for key, names := range binds {
+			for _, name := range names {
+				if val, ok := vars[name]; ok {
+					flat[key] = val
+					break
+				}
+			}
+		}
+	}
+	keyvals, err := Nest(flat, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%w in environment variables", err)
+	}
+	return keyvals, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAutomaticEnv(t *testing.T) {
+	t.Setenv("MYAPP_PORT", "9090")
+	t.Setenv("MYAPP_SERVER_HOST", "example.com")
+	t.Setenv("MYAPP_LOG__FILE_NAME", "app.log")
+	t.Setenv("OTHER_PORT", "1")
+	t.Setenv("MYAPP_BAD__", "x")
+
+	c := New()
+	if err := c.LoadBytes([]byte(`{"port": 8080, "server": {"host": "localhost", "tls": true}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AutomaticEnv("myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("port"); got != 9090 {
+		t.Errorf("port: got %d, want the variable", got)
+	}
+	if got := c.GetStr("server.host"); got != "example.com" {
+		t.Errorf("server.host: got %q", got)
+	}
+	if !c.GetBool("server.tls") {
+		t.Error("server.tls: the variable hid the rest of the object")
+	}
+	if got := c.GetStr("log.file.name"); got != "" {
+		t.Errorf("log.file.name: got %q with the default separator", got)
+	}
+
+	if err := c.AutomaticEnv("MYAPP_", WithEnvSeparator("__")); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("log.file_name"); got != "app.log" {
+		t.Errorf("log.file_name: got %q with separator __", got)
+	}
+	if got := c.GetStr("server_host"); got != "example.com" {
+		t.Errorf("server_host: got %q with separator __", got)
+	}
+
+	// ReloadEnv picks up changed variables
+	t.Setenv("MYAPP_PORT", "7070")
+	if err := c.ReloadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("port"); got != 7070 {
+		t.Errorf("port after ReloadEnv: got %d", got)
+	}
+
+	if err := c.AutomaticEnv("myapp", WithEnvSeparator("")); err == nil {
+		t.Error("accepted an empty separator")
+	}
+	t.Setenv("MYAPP_DB", "x")
+	t.Setenv("MYAPP_DB_HOST", "y")
+	if err := New().AutomaticEnv("myapp"); err == nil {
+		t.Error("accepted a variable conflicting with a nested one")
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://primary")
+	t.Setenv("DB_URL", "postgres://fallback")
+	t.Setenv("MYAPP_DB_URL", "postgres://auto")
+
+	c := New()
+	c.SetDefault("db.url", "postgres://default")
+	if err := c.AutomaticEnv("myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindEnv("db.url", "MISSING", "DATABASE_URL", "DB_URL"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.url"); got != "postgres://primary" {
+		t.Errorf("got %q, want the first variable that is set", got)
+	}
+	c.mu.RLock()
+	vars := c.envVars("db.url")
+	c.mu.RUnlock()
+	if want := []string{"MISSING", "DATABASE_URL", "DB_URL", "MYAPP_DB_URL"}; !slices.Equal(vars, want) {
+		t.Errorf("got variables %v, want %v", vars, want)
+	}
+
+	// binding again replaces the variables
+	if err := c.BindEnv("db.url", "DB_URL"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.url"); got != "postgres://fallback" {
+		t.Errorf("after rebinding: got %q", got)
+	}
+	if err := c.BindEnv("db.url", "MISSING"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.url"); got != "postgres://auto" {
+		t.Errorf("unset binding: got %q, want the AutomaticEnv variable", got)
+	}
+
+	if err := c.BindEnv("", "X"); err == nil {
+		t.Error("bound an empty key")
+	}
+	if err := c.BindEnv("db.url"); err == nil {
+		t.Error("bound no variables")
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrKeyNotFound is reported for keys that are neither set nor have a default.
+	ErrKeyNotFound = errors.New("config: key not found")
+	// ErrTypeMismatch is reported for values whose type cannot be converted to the requested one,
+	// such as an object requested as an integer.
+	ErrTypeMismatch = errors.New("config: type mismatch")
+	// ErrParse is reported for strings that cannot be parsed as the requested type, such as "abc"
+	// requested as an integer.
+	ErrParse = errors.New("config: cannot parse value")
+	// ErrOutOfRange is reported for numbers that do not fit the requested type.
+	ErrOutOfRange = errors.New("config: value out of range")
+	// ErrUnknownKey is reported for keys that match no field of the struct they are decoded into,
+	// see DisallowUnknownKeys.
+	ErrUnknownKey = errors.New("config: unknown key")
+	// ErrFrozen is reported for changes of a configuration that was frozen with Freeze.
+	ErrFrozen = errors.New("config: configuration is frozen")
+)
+
+// KeyError reports a failure concerning the value of a key. Err wraps one of ErrKeyNotFound,
+// ErrTypeMismatch, ErrParse, ErrOutOfRange, ErrUnknownKey and ErrFrozen, so callers can match it
+// with errors.Is and get the key with errors.As.
+type KeyError struct {
+	Key string // dot separated path of the key, with indexes of array elements in brackets
+	Err error
+}
+
+// Error returns the key and the reason, such as `config: key "port": type mismatch: ...`.
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("config: key %q: %s", e.Key, strings.TrimPrefix(e.Err.Error(), "config: "))
+}
+
+// Unwrap returns the reason of the failure.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// convertE converts val to the type T like ConvertTo, but fails with ErrParse for strings that
+// are not valid values of T, with ErrTypeMismatch for objects, arrays, nulls and numbers with a
+// fraction requested as integers, and with ErrOutOfRange for numbers beyond the range of T.
+func convertE[T configtype](val any) (T, error) {
+	var t T
+	if v, ok := val.(T); ok {
+		return v, nil
+	}
+	switch v := val.(type) {
+	case string:
+		var res any
+		var err error
+		switch any(t).(type) {
+		case int:
+			res, err = strconv.Atoi(v)
+		case int64:
+			res, err = strconv.ParseInt(v, 10, 64)
+		case float64:
+			res, err = strconv.ParseFloat(v, 64)
+		case bool:
+			res, err = parseBool(v)
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return t, fmt.Errorf("%w: %s as %s", ErrOutOfRange, v, typeName(t))
+		}
+		if err != nil {
+			return t, fmt.Errorf("%w %q as %s", ErrParse, v, typeName(t))
+		}
+		return res.(T), nil
+	case float64:
+		switch any(t).(type) {
+		case int, int64:
+			if v != math.Trunc(v) {
+				return t, fmt.Errorf("%w: %v is not an integer", ErrTypeMismatch, v)
+			}
+			if v < math.MinInt64 || v >= math.MaxInt64 || int64(int(v)) != int64(v) {
+				return t, fmt.Errorf("%w: %v as %s", ErrOutOfRange, v, typeName(t))
+			}
+		}
+		return ConvertTo[T](v), nil
+	case int64:
+		if _, ok := any(t).(int); ok && int64(int(v)) != v {
+			return t, fmt.Errorf("%w: %d as %s", ErrOutOfRange, v, typeName(t))
+		}
+		return ConvertTo[T](v), nil
+	case int, bool:
+		return ConvertTo[T](v), nil
+	}
+	name := typeName(val)
+	if val == nil {
+		name = "null"
+	}
+	return t, fmt.Errorf("%w: cannot convert %s to %s", ErrTypeMismatch, name, typeName(t))
+}
+
+// parseBool parses the boolean strings accepted by strconv.ParseBool as well as "yes" and "no"
+// in any case.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
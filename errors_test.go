@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEErrors(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{
+		"name": "app", "port": "8080", "ratio": 1.5, "big": 1e30, "on": "yes",
+		"word": "abc", "huge": "99999999999999999999", "server": {"port": 80}, "none": null
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("fallback", 3)
+
+	check := func(t *testing.T, key string, err, want error) {
+		t.Helper()
+		if want == nil {
+			if err != nil {
+				t.Errorf("%s: %v", key, err)
+			}
+			return
+		}
+		var kerr *KeyError
+		if !errors.Is(err, want) || !errors.As(err, &kerr) || kerr.Key != key {
+			t.Errorf("%s: got %v, want a *KeyError for the key wrapping %v", key, err, want)
+		}
+	}
+	for _, tt := range []struct {
+		key  string
+		want error
+	}{
+		{"port", nil},
+		{"server.port", nil},
+		{"fallback", nil},
+		{"missing", ErrKeyNotFound},
+		{"server.missing", ErrKeyNotFound},
+		{"ratio", ErrTypeMismatch},
+		{"server", ErrTypeMismatch},
+		{"none", ErrTypeMismatch},
+		{"word", ErrParse},
+		{"huge", ErrOutOfRange},
+		{"big", ErrOutOfRange},
+	} {
+		_, err := GetE[int](c, tt.key)
+		check(t, tt.key, err, tt.want)
+	}
+	if got, err := c.GetBoolE("on"); err != nil || !got {
+		t.Errorf("on: got %v, %v, want true", got, err)
+	}
+	_, err := c.GetBoolE("word")
+	check(t, "word", err, ErrParse)
+	if got, err := c.GetStrE("ratio"); err != nil || got != "1.500000" {
+		t.Errorf("ratio as string: got %q, %v", got, err)
+	}
+
+	err = (&KeyError{Key: "port", Err: ErrParse}).Unwrap()
+	if err != ErrParse {
+		t.Errorf("Unwrap: got %v", err)
+	}
+	if got := (&KeyError{Key: "port", Err: ErrKeyNotFound}).Error(); got != `config: key "port": key not found` {
+		t.Errorf("Error: got %q", got)
+	}
+}
+
+func TestAsStringMapStrictErrors(t *testing.T) {
+	c := New()
+	c.Set("servers", []any{map[string]any{"host": "a"}})
+	_, err := c.AsStringMapStrict("")
+	var kerr *KeyError
+	if !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &kerr) || kerr.Key != "servers" {
+		t.Fatalf("got %v, want a *KeyError for servers wrapping ErrTypeMismatch", err)
+	}
+}
+
+func TestGetIntoErrors(t *testing.T) {
+	c := New()
+	c.Set("server", map[string]any{"port": "http", "timeout": "soon", "hosts": []any{"a", "b", "c"}})
+	var dst struct {
+		Port    int
+		Timeout time.Duration
+	}
+	var arr [2]string
+	for _, tt := range []struct {
+		key, path string
+		dst       any
+		want      error
+	}{
+		{"missing", "missing", &dst, ErrKeyNotFound},
+		{"server", "server.Port", &dst, ErrParse},
+		{"server.timeout", "server.timeout", &dst.Timeout, ErrParse},
+		{"server.hosts", "server.hosts", &arr, ErrTypeMismatch},
+	} {
+		err := c.GetInto(tt.key, tt.dst)
+		var kerr *KeyError
+		if !errors.Is(err, tt.want) || !errors.As(err, &kerr) || !strings.EqualFold(kerr.Key, tt.path) {
+			t.Errorf("%s: got %v, want a *KeyError for %s wrapping %v", tt.key, err, tt.path, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package etcdsource provides a configuration provider reading the keys below a prefix of an
+// etcd cluster and watching them for changes:
+//
+//	src := etcdsource.New(client, "/myapp/")
+//	err := config.Config().StartWatch(ctx, src)
+//
+// The keys below the prefix are split at "/" into nested configuration keys, so the etcd key
+// "/myapp/db/host" sets "host" inside the object "db". Values are decoded with
+// config.ParseValue.
+package etcdsource
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// Source is a config.Provider reading the keys below a prefix of an etcd cluster.
+type Source struct {
+	client *clientv3.Client
+	prefix string
+
+	mu  sync.Mutex
+	kvs map[string]any // values by key relative to the prefix, as of rev
+	rev int64
+}
+
+// New returns a Source reading the keys below prefix using client.
+func New(client *clientv3.Client, prefix string) *Source {
+	return &Source{client: client, prefix: prefix}
+}
+
+// Name identifies the source by its prefix.
+func (s *Source) Name() string {
+	return "etcd:" + s.prefix
+}
+
+// Load reads the current values of the keys below the prefix.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	kvs := make(map[string]any, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs[s.key(kv.Key)] = config.ParseValue(kv.Value)
+	}
+	s.mu.Lock()
+	s.kvs, s.rev = kvs, resp.Header.Revision
+	s.mu.Unlock()
+	return config.Nest(kvs, "/")
+}
+
+// Watch reports the document after every change of the keys below the prefix, starting after
+// the revision read by the last Load. If Load was not called or the previous watch failed, the
+// keys are read again first and the document is reported right away.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	s.mu.Lock()
+	kvs, rev := s.kvs, s.rev
+	s.mu.Unlock()
+	if kvs == nil {
+		// the previous watch failed, so changes may have been missed
+		doc, err := s.Load(ctx)
+		if err != nil {
+			return err
+		}
+		fn(doc)
+		s.mu.Lock()
+		kvs, rev = s.kvs, s.rev
+		s.mu.Unlock()
+	}
+
+	for resp := range s.client.Watch(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+		if err := resp.Err(); err != nil {
+			s.mu.Lock()
+			s.kvs = nil
+			s.mu.Unlock()
+			return err
+		}
+		for _, ev := range resp.Events {
+			if ev.Type == clientv3.EventTypeDelete {
+				delete(kvs, s.key(ev.Kv.Key))
+			} else {
+				kvs[s.key(ev.Kv.Key)] = config.ParseValue(ev.Kv.Value)
+			}
+		}
+		s.mu.Lock()
+		s.rev = resp.Header.Revision
+		s.mu.Unlock()
+
+		doc, err := config.Nest(kvs, "/")
+		if err != nil {
+			return err
+		}
+		fn(doc)
+	}
+	return nil
+}
+
+// key returns the etcd key k relative to the prefix.
+func (s *Source) key(k []byte) string {
+	return strings.Trim(strings.TrimPrefix(string(k), s.prefix), "/")
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateExample writes an example configuration document in format, "json", "yaml" or "toml",
+// to w. The document contains every declared key, nested according to the key paths and set to
+// its default value or, for keys without one, to a placeholder of the type of its current value,
+// such as 0 or "", or to "" if it is not set. Keys without a default that only describe an object
+// of other declared keys are not set. In YAML and TOML documents the descriptions of the keys are
+// written as comments above them, and keys declared with Require are marked as required.
+func (c *Configuration) GenerateExample(w io.Writer, format string) error {
+	c.mu.RLock()
+	delim := c.delimiter()
+	flat := make(map[string]any)
+	comments := make(map[string][]string)
+	declared := c.declaredKeys()
+	for key := range declared {
+		if desc := c.descs[key]; desc != "" {
+			comments[key] = strings.Split(desc, "\n")
+		}
+		if c.required[key] {
+			comments[key] = append(comments[key], "required")
+		}
+		val, ok := c.defaults[key]
+		if !ok && hasNested(declared, key, delim) {
+			// described sections only contribute their comments
+			continue
+		}
+		if !ok {
+			cur, _ := findPath(c.keyvals, key, delim)
+			val = placeholder(resolve(cur))
+		}
+		flat[key] = val
+	}
+	c.mu.RUnlock()
+
+	doc, err := Nest(flat, delim)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(doc, "", "  ")
+		data = append(data, '\n')
+	case "yaml", "yml":
+		data, err = exampleYAML(doc, comments, delim)
+	case "toml":
+		data, err = exampleTOML(doc, comments, delim)
+	default:
+		return fmt.Errorf("config: unsupported example format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// hasNested reports whether a key nested below key is declared.
+func hasNested(declared map[string]bool, key, delim string) bool {
+	for k := range declared {
+		if strings.HasPrefix(k, key+delim) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns the example value of a key without a default whose current value is val.
+func placeholder(val any) any {
+	switch val.(type) {
+	case int, int64:
+		return 0
+	case float64:
+		return 0.0
+	case bool:
+		return false
+	case []any:
+		return []any{}
+	case map[string]any:
+		return map[string]any{}
+	default:
+		return ""
+	}
+}
+
+// exampleYAML encodes doc as a YAML document with the comments of its keys, given by their key
+// paths joined with delim.
+func exampleYAML(doc map[string]any, comments map[string][]string, delim string) ([]byte, error) {
+	node, err := yamlNode(doc, "", comments, delim)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(node)
+}
+
+// yamlNode returns the YAML mapping node of m, whose keys are nested below prefix.
+func yamlNode(m map[string]any, prefix string, comments map[string][]string, delim string) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range sortedKeys(m) {
+		key := &yaml.Node{}
+		if err := key.Encode(k); err != nil {
+			return nil, err
+		}
+		key.HeadComment = strings.Join(comments[prefix+k], "\n")
+		var val *yaml.Node
+		if sub, ok := m[k].(map[string]any); ok && len(sub) > 0 {
+			var err error
+			if val, err = yamlNode(sub, prefix+k+delim, comments, delim); err != nil {
+				return nil, err
+			}
+		} else {
+			val = &yaml.Node{}
+			if err := val.Encode(m[k]); err != nil {
+				return nil, err
+			}
+		}
+		node.Content = append(node.Content, key, val)
+	}
+	return node, nil
+}
+
+// exampleTOML encodes doc as a TOML document with the comments of its keys, given by their key
+// paths joined with delim. Objects nested in objects become tables, all other values are written
+// inline.
+func exampleTOML(doc map[string]any, comments map[string][]string, delim string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeTOMLTable(&buf, doc, nil, "", comments, delim)
+	return buf.Bytes(), err
+}
+
+// writeTOMLTable writes the table m, whose TOML keys are the segments of table, to buf.
+func writeTOMLTable(buf *bytes.Buffer, m map[string]any, table []string, prefix string, comments map[string][]string, delim string) error {
+	var tables []string
+	for _, k := range sortedKeys(m) {
+		if sub, ok := m[k].(map[string]any); ok && len(sub) > 0 {
+			tables = append(tables, k)
+			continue
+		}
+		line, err := tomlLine(k, m[k])
+		if err != nil {
+			return err
+		}
+		writeComments(buf, comments[prefix+k])
+		buf.WriteString(line)
+	}
+	for _, k := range tables {
+		key, err := tomlLine(k, 0)
+		if err != nil {
+			return err
+		}
+		sub := append(slices.Clone(table), strings.TrimSuffix(key, " = 0\n"))
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeComments(buf, comments[prefix+k])
+		fmt.Fprintf(buf, "[%s]\n", strings.Join(sub, "."))
+		if err := writeTOMLTable(buf, m[k].(map[string]any), sub, prefix+k+delim, comments, delim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tomlLine returns the TOML key/value pair of key and val, with tables written inline.
+func tomlLine(key string, val any) (string, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.SetTablesInline(true)
+	if err := enc.Encode(map[string]any{key: val}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeComments writes lines as comment lines to buf.
+func writeComments(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(strings.TrimSpace("# "+line) + "\n")
+	}
+}
+
+// Flatten is the inverse of Nest: it converts the nested objects of doc into key paths separated
+// by sep, so {"db": {"host": "x"}} becomes {"db.host": "x"}. Arrays are kept as values and empty
+// objects as empty objects, so Nest restores doc.
+func Flatten(doc map[string]any, sep string) map[string]any {
+	flat := make(map[string]any, len(doc))
+	flattenInto(flat, "", doc, sep)
+	return flat
+}
+
+// flattenInto stores the values of m in flat under their key paths below prefix.
+func flattenInto(flat map[string]any, prefix string, m map[string]any, sep string) {
+	for key, val := range m {
+		sub, ok := resolve(val).(map[string]any)
+		if !ok || len(sub) == 0 {
+			flat[prefix+key] = val
+			continue
+		}
+		flattenInto(flat, prefix+key+sep, sub, sep)
+	}
+}
+
+// Nest converts a map of key paths separated by sep, such as the keys of a key-value store, into
+// nested maps. It fails if a key is also the prefix of other keys. Sources use it to map flat
+// key spaces to configuration documents.
+func Nest(flat map[string]any, sep string) (map[string]any, error) {
+	doc := make(map[string]any)
+	for _, key := range sortedKeys(flat) {
+		parts := strings.Split(key, sep)
+		m := doc
+		for i, part := range parts[:len(parts)-1] {
+			switch sub := m[part].(type) {
+			case map[string]any:
+				m = sub
+			case nil:
+				if _, ok := m[part]; ok {
+					return nil, fmt.Errorf("config: key %q conflicts with key %q", key, strings.Join(parts[:i+1], sep))
+				}
+				next := make(map[string]any)
+				m[part] = next
+				m = next
+			default:
+				return nil, fmt.Errorf("config: key %q conflicts with key %q", key, strings.Join(parts[:i+1], sep))
+			}
+		}
+		last := parts[len(parts)-1]
+		if _, ok := m[last]; ok {
+			return nil, fmt.Errorf("config: key %q conflicts with a nested key", key)
+		}
+		m[last] = flat[key]
+	}
+	return doc, nil
+}
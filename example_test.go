@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// exampleConfig returns a configuration declaring keys of all kinds.
+func exampleConfig() *Configuration {
+	c := New()
+	c.SetDefault("server.host", "localhost")
+	c.SetDefault("server.port", 8080)
+	c.SetDefault("server.tls.enabled", false)
+	c.SetDefault("tags", []any{"a", "b"})
+	c.SetDefault("limits", map[string]any{})
+	c.Describe("server.port", "port to listen on")
+	c.Describe("server.tls", "TLS settings\nof the listener")
+	c.Describe("token", "API token")
+	c.Require("token")
+	c.Set("retries", 3)
+	c.Describe("retries", "number of retries")
+	return c
+}
+
+func TestGenerateExample(t *testing.T) {
+	for _, format := range []string{"json", "yaml", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			c := exampleConfig()
+			var buf bytes.Buffer
+			if err := c.GenerateExample(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if format != "json" {
+				// comments of nested keys are indented in YAML
+				var lines []string
+				for _, line := range strings.Split(out, "\n") {
+					lines = append(lines, strings.TrimSpace(line))
+				}
+				unindented := strings.Join(lines, "\n")
+				for _, comment := range []string{"# port to listen on\n", "# TLS settings\n# of the listener\n[server.tls]", "# API token\n# required\n"} {
+					if format == "yaml" {
+						comment = strings.Replace(comment, "[server.tls]", "tls:", 1)
+					}
+					if !strings.Contains(unindented, comment) {
+						t.Errorf("comment %q missing from\n%s", comment, out)
+					}
+				}
+			}
+
+			read := New()
+			if err := read.ReadFrom(strings.NewReader(out), Format("."+format)); err != nil {
+				t.Fatalf("%v in\n%s", err, out)
+			}
+			if err := c.ValidateBytes(must(json.Marshal(read.AllSettings()))); err != nil {
+				t.Errorf("example does not validate: %v\n%s", err, out)
+			}
+			for key, want := range map[string]any{
+				"server.host":        "localhost",
+				"server.port":        8080,
+				"server.tls.enabled": false,
+				"tags.1":             "b",
+				"token":              "",
+				"retries":            0,
+			} {
+				if got, _ := read.Get(key); !valuesEqual(got, want) {
+					t.Errorf("%s: got %v, want %v in\n%s", key, got, want, out)
+				}
+			}
+			if got, ok := read.Get("limits"); !ok || len(got.(map[string]any)) != 0 {
+				t.Errorf("limits: got %v, want an empty object", got)
+			}
+		})
+	}
+}
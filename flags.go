@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// flagBinding is a command line flag bound to a key.
+type flagBinding struct {
+	flag *flag.Flag
+	set  *flag.FlagSet // set the flag belongs to, nil if bound with BindFlag
+}
+
+// BindFlag binds key, which may name a nested key such as "db.url", to the command line flag f.
+// Once f is given on the command line, its value goes to the flags layer and overrides the
+// loaded documents and environment variables; until then key keeps its value from the lower
+// layers. A flag bound on its own counts as given when its value differs from its default.
+// Binding a key again replaces its flag.
+//
+// The flags are read when BindFlag is called and again by ReloadFlags, so call it after parsing
+// the command line.
+func (c *Configuration) BindFlag(key string, f *flag.Flag) error {
+	if key == "" {
+		return fmt.Errorf("config: empty key")
+	}
+	if f == nil {
+		return fmt.Errorf("config: nil flag bound to key %q", key)
+	}
+	c.mu.Lock()
+	if c.flagBinds == nil {
+		c.flagBinds = make(map[string]flagBinding)
+	}
+	c.flagBinds[key] = flagBinding{flag: f}
+	c.mu.Unlock()
+	return c.ReloadFlags()
+}
+
+// BindFlagSet binds every flag defined in fs to the key of the flag name, see BindFlag. Flags
+// count as given when they were set while parsing fs.
+func (c *Configuration) BindFlagSet(fs *flag.FlagSet) error {
+	c.mu.Lock()
+	if c.flagBinds == nil {
+		c.flagBinds = make(map[string]flagBinding)
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		c.flagBinds[f.Name] = flagBinding{flag: f, set: fs}
+	})
+	c.mu.Unlock()
+	return c.ReloadFlags()
+}
+
+// ReloadFlags reads the flags bound by BindFlag and BindFlagSet again and replaces the flags
+// layer with the values of those given on the command line, notifying the changes like a
+// reload.
+func (c *Configuration) ReloadFlags() error {
+	c.mu.RLock()
+	binds := maps.Clone(c.flagBinds)
+	c.mu.RUnlock()
+
+	given := make(map[*flag.FlagSet]map[string]bool)
+	flat := make(map[string]any, len(binds))
+	for key, b := range binds {
+		if b.set == nil {
+			if b.flag.Value.String() == b.flag.DefValue {
+				continue
+			}
+		} else {
+			names, ok := given[b.set]
+			if !ok {
+				names = make(map[string]bool)
+				b.set.Visit(func(f *flag.Flag) { names[f.Name] = true })
+				given[b.set] = names
+			}
+			if !names[b.flag.Name] {
+				continue
+			}
+		}
+		flat[key] = flagValue(b.flag)
+	}
+	keyvals, err := Nest(flat, ".")
+	if err != nil {
+		return fmt.Errorf("%w in command line flags", err)
+	}
+	return c.SetLayer(LayerFlags, keyvals)
+}
+
+// flagValue returns the value of f as one of the types the typed getters convert, falling back
+// to its string form.
+func flagValue(f *flag.Flag) any {
+	if g, ok := f.Value.(flag.Getter); ok {
+		switch v := g.Get().(type) {
+		case bool, int, int64, float64, string, map[string]any, []any:
+			return v
+		}
+	}
+	return f.Value.String()
+}
+
+// FlagSet returns a new flag set with the given name and error handling holding a flag for
+// every declared key, see DefineFlags.
+func (c *Configuration) FlagSet(name string, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, errorHandling)
+	c.DefineFlags(fs)
+	return fs
+}
+
+// DefineFlags defines a flag in fs for every key with a default or a description that fs does
+// not define yet, named like the key and documented with its description and default. The
+// flag type follows the type of the default, or of the current value of keys without one:
+// strings, booleans and numbers get flags of their type, objects and arrays take JSON values.
+// Bind fs with BindFlagSet after parsing the command line so the given flags override the
+// configuration:
+//
+//	cfg.DefineFlags(flag.CommandLine)
+//	flag.Parse()
+//	err := cfg.BindFlagSet(flag.CommandLine)
+func (c *Configuration) DefineFlags(fs *flag.FlagSet) {
+	c.mu.RLock()
+	type decl struct {
+		key, desc string
+		val       any
+	}
+	var decls []decl
+	for _, key := range sortedKeys(c.declaredKeys()) {
+		val, ok := c.defaults[key]
+		if !ok {
+			val, _ = findPath(c.keyvals, key, c.delimiter())
+			val = resolve(val)
+		}
+		decls = append(decls, decl{key, c.descs[key], val})
+	}
+	c.mu.RUnlock()
+
+	for _, d := range decls {
+		if fs.Lookup(d.key) != nil {
+			continue
+		}
+		switch v := d.val.(type) {
+		case bool:
+			fs.Bool(d.key, v, d.desc)
+		case int:
+			fs.Int(d.key, v, d.desc)
+		case int64:
+			fs.Int64(d.key, v, d.desc)
+		case float64:
+			fs.Float64(d.key, v, d.desc)
+		case map[string]any, []any:
+			fs.Var(&jsonFlag{val: v}, d.key, strings.TrimSpace(d.desc+" (`JSON`)"))
+		case nil:
+			fs.String(d.key, "", d.desc)
+		default:
+			fs.String(d.key, fmt.Sprint(v), d.desc)
+		}
+	}
+}
+
+// jsonFlag is a flag.Value holding a JSON encoded object or array.
+type jsonFlag struct {
+	val any
+}
+
+// String returns the JSON encoding of the value.
+func (f *jsonFlag) String() string {
+	if f == nil || f.val == nil {
+		return ""
+	}
+	data, err := json.Marshal(f.val)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Set decodes s as a JSON object or array.
+func (f *jsonFlag) Set(s string) error {
+	var val any
+	if err := json.Unmarshal([]byte(s), &val); err != nil {
+		return err
+	}
+	switch val.(type) {
+	case map[string]any, []any:
+	default:
+		return errors.New("expected a JSON object or array")
+	}
+	f.val = val
+	return nil
+}
+
+// Get returns the decoded value.
+func (f *jsonFlag) Get() any {
+	return f.val
+}
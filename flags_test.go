@@ -0,0 +1,139 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	fs.String("host", "localhost", "")
+	fs.Bool("debug", false, "")
+	if err := fs.Parse([]string{"-port", "8080", "-debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if err := c.LoadBytes([]byte(`{"port": 1, "host": "file-host"}`)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_HOST", "env-host")
+	if err := c.AutomaticEnv("app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindFlagSet(fs); err != nil {
+		t.Fatal(err)
+	}
+	// flags given on the command line override, even with their default value
+	if got := c.GetInt("port"); got != 8080 {
+		t.Errorf("port: got %d, want the given flag", got)
+	}
+	if !c.GetBool("debug") {
+		t.Error("debug: want the given flag")
+	}
+	if got := c.GetStr("host"); got != "env-host" {
+		t.Errorf("host: got %q, want the variable as the flag was not given", got)
+	}
+
+	*port = 9090
+	if err := c.ReloadFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("port"); got != 9090 {
+		t.Errorf("port after ReloadFlags: got %d", got)
+	}
+}
+
+func TestBindFlag(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("db-url", "postgres://default", "")
+	c := New()
+	if err := c.LoadBytes([]byte(`{"db": {"url": "postgres://file", "user": "app"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	f := fs.Lookup("db-url")
+	if err := c.BindFlag("db.url", f); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.url"); got != "postgres://file" {
+		t.Errorf("got %q, want the file value while the flag has its default", got)
+	}
+	if err := f.Value.Set("postgres://flag"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ReloadFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.url") + " " + c.GetStr("db.user"); got != "postgres://flag app" {
+		t.Errorf("got %q, want the flag merged into the object", got)
+	}
+	if l, _ := c.Origin("db.url"); l != LayerFlags {
+		t.Errorf("got origin %v, want flags", l)
+	}
+
+	if err := c.BindFlag("", f); err == nil {
+		t.Error("bound an empty key")
+	}
+	if err := c.BindFlag("x", nil); err == nil {
+		t.Error("bound a nil flag")
+	}
+}
+
+func TestDefineFlags(t *testing.T) {
+	c := New()
+	c.SetDefault("port", 8080)
+	c.SetDefault("debug", false)
+	c.SetDefault("ratio", 0.5)
+	c.SetDefault("name", "app")
+	c.SetDefault("tags", []any{"a"})
+	c.Describe("port", "port to listen on")
+	c.Describe("server.host", "host name")
+	c.Set("server", map[string]any{"host": "localhost", "timeout": 3})
+	c.Describe("server.timeout", "timeout in seconds")
+	c.Describe("token", "API token")
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("name", "predefined", "")
+	c.DefineFlags(fs)
+	for _, tt := range []struct{ name, def, usage string }{
+		{"port", "8080", "port to listen on"},
+		{"debug", "false", ""},
+		{"ratio", "0.5", ""},
+		{"name", "predefined", ""},
+		{"tags", `["a"]`, "(`JSON`)"},
+		{"server.host", "localhost", "host name"},
+		{"server.timeout", "3", "timeout in seconds"},
+		{"token", "", "API token"},
+	} {
+		f := fs.Lookup(tt.name)
+		if f == nil {
+			t.Errorf("flag %s not defined", tt.name)
+			continue
+		}
+		if f.DefValue != tt.def || f.Usage != tt.usage {
+			t.Errorf("flag %s: got default %q and usage %q, want %q and %q", tt.name, f.DefValue, f.Usage, tt.def, tt.usage)
+		}
+	}
+
+	if err := fs.Parse([]string{"-port", "9090", "-tags", `["x", "y"]`, "-server.timeout", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindFlagSet(fs); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("port"); got != 9090 {
+		t.Errorf("port: got %d", got)
+	}
+	if got := c.GetStr("tags.1"); got != "y" {
+		t.Errorf("tags.1: got %q", got)
+	}
+	if err := fs.Parse([]string{"-tags", `"x"`}); err == nil {
+		t.Error("accepted a JSON string for an array flag")
+	}
+	if fs := c.FlagSet("other", flag.ContinueOnError); fs.Lookup("ratio") == nil || fs.Name() != "other" {
+		t.Error("FlagSet did not define the declared keys")
+	}
+}
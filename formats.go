@@ -0,0 +1,150 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format identifies a registered configuration format by its file name extension.
+type Format string
+
+// The formats registered by the package.
+const (
+	JSON       Format = ".json"
+	JSONC      Format = ".jsonc"
+	YAML       Format = ".yaml"
+	TOML       Format = ".toml"
+	INI        Format = ".ini"
+	DotEnv     Format = ".env"
+	HCL        Format = ".hcl"
+	XML        Format = ".xml"
+	Properties Format = ".properties"
+	Plist      Format = ".plist"
+)
+
+// format is a codec for configuration documents registered with RegisterFormat.
+type format struct {
+	decode func([]byte) (map[string]any, error)
+	encode func(map[string]any) ([]byte, error) // nil for read-only formats
+	lazy   func([]byte) (map[string]any, error) // lazy decoder, nil if not supported
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]format) // formats by lower case extension with leading dot
+)
+
+func init() {
+	// the built-in JSON format is registered like any other, with support for lazy decoding
+	formats[".json"] = format{decode: decodeJSON, encode: encodeJSON, lazy: decodeLazy}
+}
+
+// RegisterFormat registers the decoder dec and encoder enc for configuration files with the file
+// name extension ext, such as ".json". The leading dot is optional and extensions are matched
+// case-insensitively. ReadFile, WriteFile, Reload, WatchFile and the other file based functions
+// pick the codec by the extension of the file name; files whose extension has no registered
+// format are treated as JSON. enc may be nil for formats that can only be read. Registering an
+// extension again replaces its codec, which also allows overriding the built-in JSON format.
+// RegisterFormat is safe for concurrent use.
+func RegisterFormat(ext string, dec func([]byte) (map[string]any, error), enc func(map[string]any) ([]byte, error)) {
+	formatsMu.Lock()
+	formats[normalizeExt(ext)] = format{decode: dec, encode: enc}
+	formatsMu.Unlock()
+}
+
+// Formats returns the registered extensions in lexical order.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return sortedKeys(formats)
+}
+
+// Decode decodes the configuration document data in the format registered for the extension of
+// name, JSON by default, like ReadFile does for a file with that name. Sources outside the
+// package use it to decode fetched documents.
+func Decode(name string, data []byte) (map[string]any, error) {
+	if MaxFileSize > 0 && int64(len(data)) > MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes: %s", ErrFileTooLarge, MaxFileSize, name)
+	}
+	return formatOf(name).decode(data)
+}
+
+// formatOf returns the format registered for the extension of fname, falling back to JSON.
+func formatOf(fname string) format {
+	if f, ok := lookupFormat(filepath.Ext(fname)); ok {
+		return f
+	}
+	f, _ := lookupFormat(".json")
+	return f
+}
+
+// lookupFormat returns the format registered for the extension ext.
+func lookupFormat(ext string) (format, bool) {
+	if ext == "" {
+		return format{}, false
+	}
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[normalizeExt(ext)]
+	return f, ok
+}
+
+// normalizeExt returns ext in lower case with a leading dot.
+func normalizeExt(ext string) string {
+	return "." + strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// encodeFile encodes keyvals in the format of fname.
+func encodeFile(fname string, keyvals map[string]any) ([]byte, error) {
+	f := formatOf(fname)
+	if f.encode == nil {
+		return nil, fmt.Errorf("config: format of %s cannot be written", fname)
+	}
+	return f.encode(keyvals)
+}
+
+// encodeJSON encodes keyvals as indented JSON.
+func encodeJSON(keyvals map[string]any) ([]byte, error) {
+	data, err := json.MarshalIndent(keyvals, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// normalizeValue converts the values produced by the decoder of a format into the kinds of
+// values of a decoded JSON document: mapping keys are converted to strings, timestamps to
+// RFC 3339 strings and other types that describe themselves, such as local dates, with their
+// String method. Integers are kept as they are.
+func normalizeValue(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		for key, elem := range v {
+			v[key] = normalizeValue(elem)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = normalizeValue(elem)
+		}
+		return m
+	case []any:
+		for i, elem := range v {
+			v[i] = normalizeValue(elem)
+		}
+		return v
+	case uint64:
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return val
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import "log"
+
+// Freeze makes c read-only for code changing it, so a stray call cannot modify the configuration
+// after startup. Afterwards SetE, DeleteE, Update, RollbackTo and ResolveRefs fail with an error
+// matching ErrFrozen, while Set, SetAll, SetIfEquals, SetIfAbsent, Delete, Clear, Merge, Restore
+// and the methods of Namespace discard the change and log the error, see FreezeStrict. Loading
+// and reloading documents, environment variables and flags still update c. A configuration
+// cannot be unfrozen.
+func (c *Configuration) Freeze() {
+	c.mu.Lock()
+	c.frozen = true
+	c.mu.Unlock()
+}
+
+// FreezeStrict is like Freeze but makes the methods discarding changes panic with the error
+// instead, so stray changes are found in tests.
+func (c *Configuration) FreezeStrict() {
+	c.mu.Lock()
+	c.frozen, c.strictFreeze = true, true
+	c.mu.Unlock()
+}
+
+// Frozen reports whether c was frozen with Freeze or FreezeStrict.
+func (c *Configuration) Frozen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frozen
+}
+
+// SetE is like Set but fails with a *KeyError matching ErrFrozen if c is frozen.
+func (c *Configuration) SetE(key string, val any) error {
+	if err := c.set(key, val); err != nil {
+		return err
+	}
+	c.checkNamespace(key)
+	return nil
+}
+
+// DeleteE is like Delete but fails with a *KeyError matching ErrFrozen if c is frozen.
+func (c *Configuration) DeleteE(key string) error {
+	if err := c.delete(key); err != nil {
+		return err
+	}
+	c.checkNamespace(key)
+	return nil
+}
+
+// checkFrozen returns the error for a change of key, or of the whole configuration if key is
+// empty, if c is frozen. The caller must hold c.mu.
+func (c *Configuration) checkFrozen(key string) error {
+	switch {
+	case !c.frozen:
+		return nil
+	case key == "":
+		return ErrFrozen
+	default:
+		return &KeyError{Key: key, Err: ErrFrozen}
+	}
+}
+
+// rejected reports err for a change discarded because c is frozen, by panicking if c was frozen
+// with FreezeStrict and by logging it otherwise.
+func (c *Configuration) rejected(err error) {
+	c.mu.RLock()
+	strict := c.strictFreeze
+	c.mu.RUnlock()
+	if strict {
+		panic(err)
+	}
+	log.Print(err)
+}
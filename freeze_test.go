@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+// frozenConfig returns a configuration with a few values and a history of two versions, frozen
+// with freeze.
+func frozenConfig(t *testing.T, freeze func(*Configuration)) *Configuration {
+	t.Helper()
+	c := New()
+	c.SetHistorySize(2)
+	if err := c.LoadBytes([]byte(`{"port": 80, "server": {"host": "a"}, "ref": "${port}"}`)); err != nil {
+		t.Fatal(err)
+	}
+	freeze(c)
+	return c
+}
+
+func TestFreeze(t *testing.T) {
+	setters := []struct {
+		name string
+		fn   func(c *Configuration)
+	}{
+		{"Set", func(c *Configuration) { c.Set("port", 81) }},
+		{"Set nested", func(c *Configuration) { c.Set("server.host", "b") }},
+		{"SetAll", func(c *Configuration) { c.SetAll(map[string]any{"port": 81}) }},
+		{"SetIfEquals", func(c *Configuration) { c.SetIfEquals("port", 80, 81) }},
+		{"SetIfAbsent", func(c *Configuration) { c.SetIfAbsent("name", "x") }},
+		{"Delete", func(c *Configuration) { c.Delete("port") }},
+		{"Delete nested", func(c *Configuration) { c.Delete("server.host") }},
+		{"Clear", func(c *Configuration) { c.Clear() }},
+		{"Merge", func(c *Configuration) {
+			other := New()
+			other.Set("port", 81)
+			c.Merge(other, MergeDeep)
+		}},
+		{"Restore", func(c *Configuration) { c.Restore(New().Snapshot()) }},
+		{"Sub.Set", func(c *Configuration) { c.Sub("server").Set("host", "b") }},
+		{"Sub.Delete", func(c *Configuration) { c.Sub("server").Delete("host") }},
+	}
+	for _, tt := range setters {
+		t.Run(tt.name, func(t *testing.T) {
+			c := frozenConfig(t, (*Configuration).Freeze)
+			before, version := c.AllSettings(), c.Version()
+			var buf bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&buf)
+			tt.fn(c)
+			if !strings.Contains(buf.String(), "configuration is frozen") {
+				t.Errorf("got log %q, want the change logged as rejected", buf.String())
+			}
+			if c.Version() != version || !valuesEqual(c.AllSettings(), before) {
+				t.Error("the frozen configuration changed")
+			}
+
+			c = frozenConfig(t, (*Configuration).FreezeStrict)
+			func() {
+				defer func() {
+					err, _ := recover().(error)
+					if !errors.Is(err, ErrFrozen) {
+						t.Errorf("got panic %v, want ErrFrozen", err)
+					}
+				}()
+				tt.fn(c)
+			}()
+			if c.Version() != version {
+				t.Error("the strictly frozen configuration changed")
+			}
+		})
+	}
+
+	c := frozenConfig(t, (*Configuration).Freeze)
+	if !c.Frozen() {
+		t.Fatal("Frozen: got false")
+	}
+	var kerr *KeyError
+	if err := c.SetE("port", 81); !errors.Is(err, ErrFrozen) || !errors.As(err, &kerr) || kerr.Key != "port" {
+		t.Errorf("SetE: got %v, want a *KeyError wrapping ErrFrozen", err)
+	}
+	if err := c.DeleteE("port"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("DeleteE: got %v", err)
+	}
+	if err := c.Update(func(tx *Tx) error { return nil }); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Update: got %v", err)
+	}
+	if err := c.RollbackTo(c.History()[0].Version()); !errors.Is(err, ErrFrozen) {
+		t.Errorf("RollbackTo: got %v", err)
+	}
+	if err := c.ResolveRefs(); !errors.Is(err, ErrFrozen) {
+		t.Errorf("ResolveRefs: got %v", err)
+	}
+
+	// loading still updates a frozen configuration
+	if err := c.LoadBytes([]byte(`{"port": 90}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("port"); got != 90 {
+		t.Errorf("got port %d, want the loaded value", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Package gcpsecretsource provides a configuration provider reading secrets from Google Cloud
+// Secret Manager into designated configuration keys:
+//
+//	client, err := secretmanager.NewClient(ctx)
+//	src := gcpsecretsource.New(client, map[string]string{
+//		"db.password": "projects/my-project/secrets/db-password",
+//		"tls":         "projects/my-project/secrets/tls/versions/3",
+//	})
+//	err = config.Config().StartWatch(ctx, src)
+//
+// Secret names without a version read the latest version, names ending in "/versions/<n>" pin
+// the secret to that version. Payloads are decoded with config.ParseValue. Loading the source,
+// once or periodically with config.StartRefresh, reads all secrets; watching it polls them every
+// Interval and only reports a new document when the latest version of a secret changed.
+package gcpsecretsource
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// API is the part of the Secret Manager client used by Source, implemented by
+// *secretmanager.Client.
+type API interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// Source is a config.Provider reading secrets from Google Cloud Secret Manager.
+type Source struct {
+	Client   API
+	Secrets  map[string]string // secret names by dot separated configuration key
+	Interval time.Duration     // polling interval for rotation detection, 0 for five minutes
+
+	mu       sync.Mutex
+	versions map[string]string // names of the versions read by the last read, by secret name
+}
+
+// New returns a Source reading secrets using client. secrets maps dot separated configuration
+// keys to the resource names of the secrets stored at them.
+func New(client API, secrets map[string]string) *Source {
+	return &Source{Client: client, Secrets: secrets}
+}
+
+// Name identifies the source.
+func (s *Source) Name() string {
+	return "gcp-secret-manager"
+}
+
+// Load reads the secrets.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	doc, _, err := s.read(ctx)
+	return doc, err
+}
+
+// Watch polls the secrets every Interval and reports the document whenever a secret version
+// changed.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			doc, rotated, err := s.read(ctx)
+			if err != nil {
+				return err
+			}
+			if rotated {
+				fn(doc)
+			}
+		}
+	}
+}
+
+// read accesses all secrets and reports whether any version differs from the last read.
+func (s *Source) read(ctx context.Context) (map[string]any, bool, error) {
+	flat := make(map[string]any, len(s.Secrets))
+	versions := make(map[string]string, len(s.Secrets))
+	for key, name := range s.Secrets {
+		resp, err := s.Client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: versionName(name)})
+		if err != nil {
+			return nil, false, fmt.Errorf("accessing secret %s: %w", name, err)
+		}
+		data := resp.GetPayload().GetData()
+		if sum := resp.GetPayload().DataCrc32C; sum != nil && int64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))) != *sum {
+			return nil, false, fmt.Errorf("secret %s: payload checksum mismatch", name)
+		}
+		flat[key] = config.ParseValue(data)
+		versions[name] = resp.GetName()
+	}
+	doc, err := config.Nest(flat, ".")
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	rotated := len(versions) != len(s.versions)
+	for name, v := range versions {
+		if s.versions[name] != v {
+			rotated = true
+		}
+	}
+	s.versions = versions
+	s.mu.Unlock()
+	return doc, rotated, nil
+}
+
+// versionName returns the name of the secret version to access for the secret name, which may
+// already name a version.
+func versionName(name string) string {
+	if strings.Contains(name, "/versions/") {
+		return name
+	}
+	return name + "/versions/latest"
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func init() {
+	RegisterFormat(".hcl", decodeHCL, nil)
+}
+
+// decodeHCL decodes an HCL document into a key-value map. Attributes become keys and blocks
+// nested objects below their type and labels, so `service "web" { port = 80 }` sets the value
+// 80 at "service.web.port"; blocks with the same type and labels are merged. Expressions are
+// evaluated without variables or functions. HCL documents can only be read.
+func decodeHCL(data []byte) (map[string]any, error) {
+	file, diags := hclsyntax.ParseConfig(data, "config.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return decodeHCLBody(file.Body.(*hclsyntax.Body))
+}
+
+// decodeHCLBody decodes the attributes and blocks of body.
+func decodeHCLBody(body *hclsyntax.Body) (map[string]any, error) {
+	keyvals := make(map[string]any, len(body.Attributes)+len(body.Blocks))
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		v, err := ctyValue(val)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", name, err)
+		}
+		keyvals[name] = v
+	}
+	for _, block := range body.Blocks {
+		sub, err := decodeHCLBody(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		path := append([]string{block.Type}, block.Labels...)
+		target, ok := getPath(keyvals, path)
+		if m, isMap := target.(map[string]any); ok && isMap {
+			deepMerge(m, sub)
+			continue
+		}
+		if ok {
+			return nil, fmt.Errorf("config: block %q conflicts with an attribute", strings.Join(path, "."))
+		}
+		setPath(keyvals, path, sub)
+	}
+	return keyvals, nil
+}
+
+// ctyValue converts an evaluated HCL value into a configuration value.
+func ctyValue(val cty.Value) (any, error) {
+	switch {
+	case val.IsNull():
+		return nil, nil
+	case !val.IsKnown():
+		return nil, fmt.Errorf("value is unknown")
+	}
+	val, _ = val.Unmark()
+	t := val.Type()
+	switch {
+	case t == cty.String:
+		return val.AsString(), nil
+	case t == cty.Bool:
+		return val.True(), nil
+	case t == cty.Number:
+		f := val.AsBigFloat()
+		if n, acc := f.Int64(); acc == 0 {
+			return n, nil
+		}
+		n, _ := f.Float64()
+		return n, nil
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		res := make([]any, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			v, err := ctyValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	case t.IsMapType() || t.IsObjectType():
+		res := make(map[string]any, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			v, err := ctyValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[key.AsString()] = v
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %s", t.FriendlyName())
+	}
+}
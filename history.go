@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SetHistorySize keeps the states of the last n versions of the configuration, including the
+// current one, so changes can be reverted with RollbackTo. Every load, reload and change, such as
+// with Set or Delete, makes a new version, see Version. Enabling the history records the current
+// state; a size of 0 or less disables the history and discards it. Each version holds a deep copy
+// of the configuration, so n should be small for large configurations.
+func (c *Configuration) SetHistorySize(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.historySize = max(n, 0)
+	switch {
+	case n <= 0:
+		c.history = nil
+	case len(c.history) == 0:
+		c.history = []*Snapshot{c.takeSnapshot()}
+	case len(c.history) > n:
+		c.history = slices.Clone(c.history[len(c.history)-n:])
+	}
+}
+
+// Version returns the version of the configuration, the number of loads, reloads and changes
+// made to it.
+func (c *Configuration) Version() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// History returns the snapshots of the versions kept in the history, oldest first.
+func (c *Configuration) History() []*Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.history)
+}
+
+// RollbackTo restores the state of version from the history like Restore, which makes a new
+// version. It fails if the version is not kept in the history or c is frozen.
+func (c *Configuration) RollbackTo(version int64) error {
+	c.mu.RLock()
+	i := slices.IndexFunc(c.history, func(s *Snapshot) bool { return s.version == version })
+	var snap *Snapshot
+	if i >= 0 {
+		snap = c.history[i]
+	}
+	c.mu.RUnlock()
+	if snap == nil {
+		return fmt.Errorf("config: version %d is not in the history", version)
+	}
+	return c.restore(snap)
+}
+
+// record makes a new version after a change and adds its state to the history. The caller must
+// hold c.mu.
+func (c *Configuration) record() {
+	c.version++
+	if c.historySize == 0 {
+		return
+	}
+	c.history = append(c.history, c.takeSnapshot())
+	if extra := len(c.history) - c.historySize; extra > 0 {
+		c.history = slices.Delete(c.history, 0, extra)
+	}
+}
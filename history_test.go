@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	c := New()
+	c.Set("n", 0)
+	if got := c.History(); len(got) != 0 {
+		t.Fatalf("got %d versions with the history disabled", len(got))
+	}
+	c.SetHistorySize(3)
+	if h := c.History(); len(h) != 1 || h[0].Version() != c.Version() {
+		t.Fatalf("got history %v, want the current state recorded", h)
+	}
+	start := c.Version()
+	for i := 1; i <= 4; i++ {
+		c.Set("n", i)
+	}
+	h := c.History()
+	if len(h) != 3 {
+		t.Fatalf("got %d versions, want the history trimmed to 3", len(h))
+	}
+	for i, snap := range h {
+		if want := start + 2 + int64(i); snap.Version() != want {
+			t.Errorf("version %d: got %d, want %d", i, snap.Version(), want)
+		}
+		if got, _ := snap.Get("n"); got != i+2 {
+			t.Errorf("version %d: got n %v, want %d", snap.Version(), got, i+2)
+		}
+	}
+
+	if err := c.RollbackTo(start + 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("n"); got != 2 {
+		t.Errorf("after RollbackTo: got n %d, want 2", got)
+	}
+	if c.Version() != start+5 {
+		t.Errorf("got version %d, want the rollback to make version %d", c.Version(), start+5)
+	}
+	if h := c.History(); h[len(h)-1].Version() != c.Version() {
+		t.Error("the rollback was not recorded")
+	}
+	if err := c.RollbackTo(start); err == nil {
+		t.Error("rolled back to a version dropped from the history")
+	}
+
+	c.SetHistorySize(1)
+	if h := c.History(); len(h) != 1 || h[0].Version() != c.Version() {
+		t.Errorf("got %d versions after shrinking the history, want the current one", len(h))
+	}
+	c.SetHistorySize(0)
+	if len(c.History()) != 0 {
+		t.Error("disabling the history kept it")
+	}
+
+	c.SetHistorySize(2)
+	c.Set("n", 9)
+	c.Freeze()
+	if err := c.RollbackTo(c.History()[0].Version()); !errors.Is(err, ErrFrozen) {
+		t.Errorf("got %v rolling back a frozen configuration, want ErrFrozen", err)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPSource is a Source fetching a configuration document from a URL with HTTP GET requests.
+// It remembers the ETag of the last response and sends it in If-None-Match, so unchanged
+// documents are not transferred again, and serves the last document without a request while it
+// is fresh according to the max-age of the Cache-Control header. Combined with StartRefresh it
+// polls a central configuration endpoint. The document is decoded in the format registered for
+// the extension of the URL path, JSON by default, and its conditional sections are applied.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client // nil for http.DefaultClient
+
+	mu      sync.Mutex
+	etag    string
+	doc     map[string]any
+	expires time.Time // end of the freshness lifetime of doc
+}
+
+// NewHTTPSource returns an HTTPSource fetching url.
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{URL: url}
+}
+
+// Name returns the URL.
+func (s *HTTPSource) Name() string {
+	return s.URL
+}
+
+// Load fetches the document, or returns the cached one if it is still fresh or the server
+// reports it unchanged.
+func (s *HTTPSource) Load(ctx context.Context) (map[string]any, error) {
+	name, _, _ := strings.Cut(s.URL, "?")
+	doc, err := s.load(ctx, func(data []byte) (map[string]any, error) {
+		return decodeDocument(formatOf(name), data, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deepCopyMap(doc), nil
+}
+
+// load implements Load, decoding fetched documents with decode. The returned document is the
+// cached one, which must not be modified.
+func (s *HTTPSource) load(ctx context.Context, decode func(data []byte) (map[string]any, error)) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.doc != nil && time.Now().Before(s.expires) {
+		return s.doc, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.etag != "" && s.doc != nil {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if s.doc == nil {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		s.expires = freshUntil(resp.Header)
+		return s.doc, nil
+	case http.StatusOK:
+	default:
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	s.doc, s.etag, s.expires = doc, "", time.Time{}
+	if !strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		s.etag = resp.Header.Get("ETag")
+		s.expires = freshUntil(resp.Header)
+	}
+	return doc, nil
+}
+
+// freshUntil returns the end of the freshness lifetime of a response with header h, which is
+// now for responses without max-age or with no-cache or no-store.
+func freshUntil(h http.Header) time.Time {
+	now := time.Now()
+	var maxAge time.Duration
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-cache" || directive == "no-store" {
+			return now
+		}
+		if v, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return now.Add(maxAge)
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// includeKey is the key of the include directive of a configuration document.
+const includeKey = "$include"
+
+// maxIncludeDepth is the maximum nesting depth of included files.
+const maxIncludeDepth = 16
+
+// include resolves the include directive of the document doc read from f. The directive is a
+// top level "$include" key holding a path or glob pattern, or an array of them, relative to the
+// directory of f. The matching files are read in order, their own directives resolved, and
+// deep-merged; the keys of doc are merged over the result, so the including document overrides
+// the files it includes. Patterns matching no files are ignored, missing files named without
+// glob metacharacters are errors, and so are include cycles. chain holds the names of the files
+// including f. The included files are appended to inputs as readInputs reports them.
+func (c *Configuration) include(f fileSource, doc map[string]any, chain []string, inputs *[]string) (map[string]any, error) {
+	directive, ok := doc[includeKey]
+	if !ok {
+		return doc, nil
+	}
+	var patterns []string
+	switch v := resolve(directive).(type) {
+	case string:
+		patterns = []string{v}
+	case []any:
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("config: %s: %q must hold strings", f.name, includeKey)
+			}
+			patterns = append(patterns, s)
+		}
+	default:
+		return nil, fmt.Errorf("config: %s: %q must be a string or an array of strings", f.name, includeKey)
+	}
+	chain = append(chain, f.name)
+	if len(chain) > maxIncludeDepth {
+		return nil, fmt.Errorf("config: %s: includes nested deeper than %d", f.name, maxIncludeDepth)
+	}
+
+	res := make(map[string]any)
+	for _, pattern := range patterns {
+		names, err := f.glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", f.name, err)
+		}
+		for _, name := range names {
+			for _, prev := range chain {
+				if f.cleanName(prev) == f.cleanName(name) {
+					return nil, fmt.Errorf("config: include cycle: %s -> %s", strings.Join(chain, " -> "), name)
+				}
+			}
+			inc := fileSource{name: name, fsys: f.fsys}
+			data, err := inc.read()
+			if err != nil {
+				return nil, err
+			}
+			*inputs = append(*inputs, name+":"+hashOf(data))
+			sub, err := c.decode(name, data)
+			if err != nil {
+				return nil, fmt.Errorf("config: %s: %w", name, err)
+			}
+			if sub, err = c.include(inc, sub, chain, inputs); err != nil {
+				return nil, err
+			}
+			deepMerge(res, sub)
+		}
+	}
+	delete(doc, includeKey)
+	deepMerge(res, doc)
+	return res, nil
+}
+
+// glob returns the names of the files matching pattern relative to the directory of f, sorted.
+// A pattern without glob metacharacters is returned as is.
+func (f fileSource) glob(pattern string) ([]string, error) {
+	if f.fsys != nil {
+		if !path.IsAbs(pattern) {
+			pattern = path.Join(path.Dir(f.name), pattern)
+		}
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			return []string{pattern}, nil
+		}
+		names, err := fs.Glob(f.fsys, pattern)
+		sort.Strings(names)
+		return names, err
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(f.name), pattern)
+	}
+	// the backslash escapes metacharacters only where it is not the path separator
+	meta := `*?[`
+	if filepath.Separator != '\\' {
+		meta += `\`
+	}
+	if !strings.ContainsAny(pattern, meta) {
+		return []string{pattern}, nil
+	}
+	names, err := filepath.Glob(pattern)
+	sort.Strings(names)
+	return names, err
+}
+
+// cleanName returns the file name in the form include compares to detect cycles, so different
+// spellings of the same file, such as "conf/../app.json" and "app.json", are equal: cleaned for
+// files of f.fsys and absolute otherwise.
+func (f fileSource) cleanName(name string) string {
+	if f.fsys != nil {
+		return path.Clean(name)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// cycles are found at the first repeated file however its name is spelled
+	writeFile(t, dir, "a.json", `{"$include": "b.json", "a": 1}`)
+	writeFile(t, dir, "b.json", `{"$include": "./a.json", "b": 1}`)
+	sep := string(filepath.Separator)
+	err := New().ReadFile(filepath.Join(dir, "sub") + sep + ".." + sep + "a.json")
+	if err == nil || !strings.Contains(err.Error(), "include cycle") || strings.Count(err.Error(), "b.json") != 1 {
+		t.Errorf("got %v, want an include cycle ending at a.json", err)
+	}
+	writeFile(t, dir, "b.json", `{"$include": `+strconv.Quote(filepath.Join(dir, "a.json"))+`}`)
+	t.Chdir(dir)
+	err = New().ReadFile("a.json")
+	if err == nil || !strings.Contains(err.Error(), "include cycle") || strings.Count(err.Error(), "b.json") != 1 {
+		t.Errorf("got %v, want an include cycle through the absolute name of a.json", err)
+	}
+
+	fsys := fstest.MapFS{
+		"conf/a.json": {Data: []byte(`{"$include": "x/../b.json"}`)},
+		"conf/b.json": {Data: []byte(`{"$include": "./a.json"}`)},
+	}
+	if err := New().ReadFS(fsys, "conf/a.json"); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("got %v, want an include cycle in the file system", err)
+	}
+}
+
+func TestIncludeGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "1.json", `{"one": 1, "n": 1}`)
+	writeFile(t, dir, "2.json", `{"two": 2, "n": 2}`)
+	patterns := `["?.json"]`
+	if runtime.GOOS != "windows" {
+		writeFile(t, dir, "x[1].json", `{"escaped": true}`)
+		patterns = `["?.json", "x\\[1\\].json"]`
+	}
+	c := New()
+	if err := c.ReadFile(writeFile(t, dir, "app.json", `{"$include": `+patterns+`, "n": 0}`)); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetInt("one") != 1 || c.GetInt("two") != 2 || c.GetInt("n") != 0 {
+		t.Errorf("got %v, want the matching files merged in order below the including file", c.AllSettings())
+	}
+	if runtime.GOOS != "windows" && !c.GetBool("escaped") {
+		t.Error("the escaped pattern did not match")
+	}
+
+	missing := writeFile(t, dir, "missing.json", `{"$include": "`+filepath.ToSlash(filepath.Join(dir, "gone.json"))+`"}`)
+	if err := New().ReadFile(missing); err == nil {
+		t.Error("included a missing file named without metacharacters")
+	}
+}
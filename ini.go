@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterFormat(".ini", decodeINI, encodeINI)
+}
+
+// decodeINI decodes an INI document into a key-value map. Keys inside a section are prefixed
+// with the section name and a dot, so "port" in section "[server]" becomes "server.port"; keys
+// before the first section are kept as they are. Keys and values are separated by "=" or ":",
+// surrounding whitespace and a pair of enclosing double or single quotes are removed from
+// values, and lines starting with ";" or "#" are comments. All values are strings.
+func decodeINI(data []byte) (map[string]any, error) {
+	keyvals := make(map[string]any)
+	section := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+			continue
+		case line[0] == '[':
+			name, ok := strings.CutSuffix(line[1:], "]")
+			if !ok {
+				return nil, fmt.Errorf("config: line %d: unterminated section header", n)
+			}
+			section = strings.TrimSpace(name)
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("config: line %d: expected key = value", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		if section != "" {
+			key = section + "." + key
+		}
+		keyvals[key] = unquote(strings.TrimSpace(line[i+1:]))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return keyvals, nil
+}
+
+// unquote removes a pair of enclosing double or single quotes from s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// encodeINI encodes keyvals as an INI document. Nested objects and dot separated keys are
+// flattened and split at their last dot into section and key, values are converted to strings
+// as by AsStringMap and quoted if they have surrounding whitespace or quotes. Values spanning
+// several lines cannot be encoded.
+func encodeINI(keyvals map[string]any) ([]byte, error) {
+	flat := make(map[string]string)
+	for key, val := range keyvals {
+		if err := flattenString(flat, key, val, true); err != nil {
+			return nil, err
+		}
+	}
+	sections := make(map[string][]string)
+	for _, key := range sortedKeys(flat) {
+		section, name := "", key
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			section, name = key[:i], key[i+1:]
+		}
+		val := flat[key]
+		if strings.ContainsAny(val, "\r\n") {
+			return nil, &KeyError{Key: key, Err: fmt.Errorf("%w: multi-line value", ErrTypeMismatch)}
+		}
+		if unquote(strings.TrimSpace(val)) != val {
+			// quoted so surrounding whitespace and quotes survive decoding
+			val = `"` + val + `"`
+		}
+		sections[section] = append(sections[section], name+" = "+val)
+	}
+
+	var buf bytes.Buffer
+	for _, section := range sortedKeys(sections) {
+		if section != "" {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(&buf, "[%s]\n", section)
+		}
+		for _, line := range sections[section] {
+			buf.WriteString(line + "\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeINI(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      map[string]any
+	}{
+		{"top level keys", "name = app\nport: 80\n", map[string]any{"name": "app", "port": "80"}},
+		{"sections", "debug = true\n[server]\nhost = localhost\n[ db ]\nurl=x\n", map[string]any{
+			"debug": "true", "server.host": "localhost", "db.url": "x",
+		}},
+		{"comments and blank lines", "; comment\n# comment\n\n  key = value  \n", map[string]any{"key": "value"}},
+		{"quotes", `a = "x y "` + "\nb = 'z'\nc = \"unbalanced'\nd = \"\"\ne = \"'q'\"\n", map[string]any{
+			"a": "x y ", "b": "z", "c": `"unbalanced'`, "d": "", "e": "'q'",
+		}},
+		{"separator in value", "url = http://host:80/?a=b\n", map[string]any{"url": "http://host:80/?a=b"}},
+		{"duplicate keys", "[s]\nk = 1\nk = 2\n", map[string]any{"s.k": "2"}},
+		{"windows line endings", "[s]\r\nk = v\r\n", map[string]any{"s.k": "v"}},
+		{"empty", "", map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeINI([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			data, err := encodeINI(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := decodeINI(data)
+			if err != nil {
+				t.Fatalf("%v in\n%s", err, data)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Fatalf("round trip: got %v, want %v from\n%s", again, got, data)
+			}
+		})
+	}
+}
+
+func TestDecodeINIErrors(t *testing.T) {
+	for _, tt := range []struct{ doc, err string }{
+		{"[server\nport = 80\n", "line 1: unterminated section header"},
+		{"[s]\nport\n", "line 2: expected key = value"},
+		{"= value\n", "line 1: expected key = value"},
+	} {
+		if _, err := decodeINI([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+}
+
+func TestEncodeININested(t *testing.T) {
+	data, err := encodeINI(map[string]any{
+		"name":   "app",
+		"server": map[string]any{"port": 80, "tls": map[string]any{"on": true}},
+		"tags":   []any{"a", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name = app\ntags = a,b\n\n[server]\nport = 80\n\n[server.tls]\non = true\n"
+	if string(data) != want {
+		t.Fatalf("got\n%s\nwant\n%s", data, want)
+	}
+	for _, keyvals := range []map[string]any{{"l": []any{map[string]any{}}}, {"s": "a\nb"}} {
+		if _, err := encodeINI(keyvals); err == nil {
+			t.Errorf("encoded %v", keyvals)
+		}
+	}
+}
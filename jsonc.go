@@ -0,0 +1,87 @@
+package config
+
+// AllowJSONComments makes ".json" files be read like ".jsonc" files, permitting comments and
+// trailing commas in them.
+var AllowJSONComments = false
+
+func init() {
+	jsonc := format{
+		decode: func(data []byte) (map[string]any, error) { return decodeJSON(stripJSONC(data)) },
+		encode: encodeJSON,
+		lazy:   func(data []byte) (map[string]any, error) { return decodeLazy(stripJSONC(data)) },
+	}
+	formats[".jsonc"] = jsonc
+	formats[".json5"] = jsonc
+}
+
+// stripJSONC returns the JSON document data with "//" line comments and "/* */" block comments
+// replaced by spaces and trailing commas before closing brackets removed. Line breaks are kept,
+// so positions reported by the JSON decoder still refer to the original document. Of the JSON5
+// extensions only comments and trailing commas are supported.
+func stripJSONC(data []byte) []byte {
+	res := make([]byte, len(data))
+	copy(res, data)
+	inString, escaped := false, false
+	for i := 0; i < len(res); i++ {
+		b := res[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case b == '"':
+			inString = true
+		case b == '/' && i+1 < len(res) && res[i+1] == '/':
+			for ; i < len(res) && res[i] != '\n'; i++ {
+				res[i] = ' '
+			}
+		case b == '/' && i+1 < len(res) && res[i+1] == '*':
+			res[i], res[i+1] = ' ', ' '
+			for i += 2; i < len(res) && !(res[i] == '*' && i+1 < len(res) && res[i+1] == '/'); i++ {
+				if res[i] != '\n' {
+					res[i] = ' '
+				}
+			}
+			if i < len(res) {
+				res[i], res[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+
+	// comments are gone, so a trailing comma is followed by whitespace and a closing bracket
+	inString, escaped = false, false
+	for i, b := range res {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case ',':
+			j := i + 1
+			for j < len(res) && (res[j] == ' ' || res[j] == '\t' || res[j] == '\n' || res[j] == '\r') {
+				j++
+			}
+			if j < len(res) && (res[j] == '}' || res[j] == ']') {
+				res[i] = ' '
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Layer identifies one of the layers a configuration is composed of. Lookups resolve keys in the
+// layers in order of decreasing precedence: values set with Set override flags, which override
+// environment variables, which override loaded documents, which override defaults.
+type Layer int
+
+const (
+	// LayerDefaults holds the default values set with SetDefault.
+	LayerDefaults Layer = iota
+	// LayerFiles holds the documents loaded from files and sources, such as by ReadFile,
+	// LoadFrom, StartRefresh or StartWatch. Later loads replace the values of earlier ones.
+	LayerFiles
+	// LayerEnv holds values taken from environment variables.
+	LayerEnv
+	// LayerFlags holds values taken from command line flags.
+	LayerFlags
+	// LayerSet holds the values set with Set.
+	LayerSet
+)
+
+// String returns the lower case name of the layer.
+func (l Layer) String() string {
+	switch l {
+	case LayerDefaults:
+		return "defaults"
+	case LayerFiles:
+		return "files"
+	case LayerEnv:
+		return "env"
+	case LayerFlags:
+		return "flags"
+	case LayerSet:
+		return "set"
+	default:
+		return fmt.Sprintf("Layer(%d)", int(l))
+	}
+}
+
+// layers holds the layers above LayerFiles and the files layer they are composed with. It is
+// only allocated once any of them is used, until then the configuration holds the files layer
+// alone.
+type layers struct {
+	files map[string]any
+	env   map[string]any
+	flags map[string]any
+	set   map[string]any
+}
+
+// compose returns the effective configuration of the layers. Objects present in the files, env
+// and flags layers are merged key by key, while values set with Set replace the whole value of
+// their key.
+func (l *layers) compose() map[string]any {
+	var view map[string]any
+	if len(l.env) == 0 && len(l.flags) == 0 {
+		view = maps.Clone(l.files)
+		if view == nil {
+			view = make(map[string]any)
+		}
+	} else {
+		view = deepCopyMap(l.files)
+		if view == nil {
+			view = make(map[string]any)
+		}
+		deepMerge(view, deepCopyMap(l.env))
+		deepMerge(view, deepCopyMap(l.flags))
+	}
+	for key, val := range l.set {
+		view[key] = val
+	}
+	return view
+}
+
+// useLayers switches the configuration to the layered representation. The caller must hold c.mu.
+func (c *Configuration) useLayers() *layers {
+	if c.layers == nil {
+		if c.keyvals == nil {
+			c.keyvals = make(map[string]any)
+		}
+		c.layers = &layers{files: c.keyvals, set: make(map[string]any)}
+		c.keyvals = c.layers.compose()
+	}
+	return c.layers
+}
+
+// clone returns a deep copy of l, nil if l is nil.
+func (l *layers) clone() *layers {
+	if l == nil {
+		return nil
+	}
+	return &layers{
+		files: deepCopyMap(l.files),
+		env:   deepCopyMap(l.env),
+		flags: deepCopyMap(l.flags),
+		set:   deepCopyMap(l.set),
+	}
+}
+
+// fileKeyvals returns the files layer. The caller must hold c.mu.
+func (c *Configuration) fileKeyvals() map[string]any {
+	if c.layers == nil {
+		return c.keyvals
+	}
+	return c.layers.files
+}
+
+// setFileKeyvals replaces the files layer and recomputes the effective configuration. The caller
+// must hold c.mu.
+func (c *Configuration) setFileKeyvals(keyvals map[string]any) {
+	if c.layers == nil {
+		c.keyvals = keyvals
+		return
+	}
+	c.layers.files = keyvals
+	c.keyvals = c.layers.compose()
+}
+
+// removeKey removes key from the set and files layers and returns its remaining effective value,
+// if any. The caller must hold c.mu.
+func (c *Configuration) removeKey(key string) (any, bool) {
+	if c.layers == nil {
+		delete(c.keyvals, key)
+		return nil, false
+	}
+	delete(c.layers.set, key)
+	delete(c.layers.files, key)
+	if len(c.layers.env) == 0 && len(c.layers.flags) == 0 {
+		delete(c.keyvals, key)
+		return nil, false
+	}
+	c.keyvals = c.layers.compose()
+	val, ok := c.keyvals[key]
+	return val, ok
+}
+
+// replaceTop replaces the value of key in the layer of highest precedence holding it.
+func (l *layers) replaceTop(key string, val any) {
+	for _, m := range []map[string]any{l.set, l.flags, l.env} {
+		if _, ok := m[key]; ok {
+			m[key] = val
+			return
+		}
+	}
+	l.files[key] = val
+}
+
+// persisted returns the values written by WriteFile and WriteSnapshot: the files layer with the
+// values set with Set, but without environment variables and flags. The caller must hold c.mu.
+func (c *Configuration) persisted() map[string]any {
+	if c.layers == nil {
+		return c.keyvals
+	}
+	res := maps.Clone(c.layers.files)
+	if res == nil {
+		res = make(map[string]any)
+	}
+	for key, val := range c.layers.set {
+		res[key] = val
+	}
+	return res
+}
+
+// SetLayer replaces the content of the env or the flags layer with keyvals, such as values
+// parsed from environment variables or command line flags, and notifies the changes of the
+// effective configuration like a reload. Keys of keyvals may hold nested objects, which are
+// merged key by key with the objects of the lower layers. Other layers cannot be replaced.
+func (c *Configuration) SetLayer(l Layer, keyvals map[string]any) error {
+	if l != LayerEnv && l != LayerFlags {
+		return fmt.Errorf("config: layer %s cannot be replaced", l)
+	}
+	c.mu.Lock()
+	ls := c.useLayers()
+	if l == LayerEnv {
+		ls.env = keyvals
+	} else {
+		ls.flags = keyvals
+	}
+	old := c.keyvals
+	c.keyvals = ls.compose()
+	new := c.keyvals
+	c.record()
+	c.mu.Unlock()
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+	return nil
+}
+
+// LayerValues returns a deep copy of the values of the layer l.
+func (c *Configuration) LayerValues(l Layer) map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var m map[string]any
+	switch {
+	case l == LayerDefaults:
+		m = c.defaults
+	case l == LayerFiles:
+		m = c.fileKeyvals()
+	case c.layers == nil:
+	case l == LayerEnv:
+		m = c.layers.env
+	case l == LayerFlags:
+		m = c.layers.flags
+	case l == LayerSet:
+		m = c.layers.set
+	}
+	res := deepCopyMap(m)
+	if res == nil {
+		res = make(map[string]any)
+	}
+	return res
+}
+
+// Origin returns the layer of highest precedence that holds key, which may be a path into nested
+// objects, and whether any layer holds it.
+func (c *Configuration) Origin(key string) (Layer, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	delim := c.delimiter()
+	if ls := c.layers; ls != nil {
+		for _, l := range []struct {
+			layer Layer
+			m     map[string]any
+		}{{LayerSet, ls.set}, {LayerFlags, ls.flags}, {LayerEnv, ls.env}} {
+			if _, ok := findPath(l.m, key, delim); ok {
+				return l.layer, true
+			}
+		}
+	}
+	if _, ok := findPath(c.fileKeyvals(), key, delim); ok {
+		return LayerFiles, true
+	}
+	if _, ok := findPath(c.defaults, key, delim); ok {
+		return LayerDefaults, true
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayerPrecedence(t *testing.T) {
+	c := New()
+	c.SetDefault("a", "defaults")
+	c.SetDefault("db", map[string]any{"user": "root"})
+	if err := c.LoadBytes([]byte(`{"a": "files", "b": "files", "db": {"host": "file-host", "port": 1}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLayer(LayerEnv, map[string]any{"a": "env", "c": "env", "db": map[string]any{"port": 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLayer(LayerFlags, map[string]any{"a": "flags", "db": map[string]any{"host": "flag-host"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		key, want string
+		layer     Layer
+	}{
+		{"a", "flags", LayerFlags},
+		{"b", "files", LayerFiles},
+		{"c", "env", LayerEnv},
+		{"db.host", "flag-host", LayerFlags},
+		{"db.port", "2", LayerEnv},
+		{"db.user", "root", LayerDefaults},
+	} {
+		if got := c.GetStr(tt.key); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.key, got, tt.want)
+		}
+		if l, ok := c.Origin(tt.key); !ok || l != tt.layer {
+			t.Errorf("%s: got origin %v, want %v", tt.key, l, tt.layer)
+		}
+	}
+
+	// values set with Set replace the whole value of their key
+	c.Set("db", map[string]any{"host": "set-host"})
+	c.Set("a", "set")
+	if got := c.GetStr("a"); got != "set" {
+		t.Errorf("a: got %q, want set", got)
+	}
+	if _, ok := c.Get("db.port"); ok {
+		t.Error("db.port: the object set with Set did not hide the lower layers")
+	}
+	if l, _ := c.Origin("db"); l != LayerSet {
+		t.Errorf("db: got origin %v, want set", l)
+	}
+
+	// the layers are kept apart
+	if got := c.LayerValues(LayerEnv); got["a"] != "env" || len(got) != 3 {
+		t.Errorf("env layer: got %v", got)
+	}
+	files := c.LayerValues(LayerFiles)
+	if files["a"] != "files" {
+		t.Errorf("files layer: got %v", files)
+	}
+	files["a"] = "changed"
+	if got := c.LayerValues(LayerFiles)["a"]; got != "files" {
+		t.Errorf("LayerValues returned the layer itself: %v", got)
+	}
+
+	// the env layer can be replaced, revealing the files layer
+	if err := c.SetLayer(LayerEnv, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Error("c: still set after the env layer was cleared")
+	}
+	for _, l := range []Layer{LayerDefaults, LayerFiles, LayerSet} {
+		if err := c.SetLayer(l, nil); err == nil {
+			t.Errorf("replaced layer %v", l)
+		}
+	}
+}
+
+func TestLayersWriteFile(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"a": 1, "b": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLayer(LayerEnv, map[string]any{"a": 2, "secret": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("b", 3)
+	fname := filepath.Join(t.TempDir(), "out.json")
+	if err := c.WriteFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] != 1.0 || got["b"] != 3.0 {
+		t.Fatalf("wrote %v, want the files layer with the values set with Set", got)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// lazyValue is a top level object or array of a configuration document whose decoding is
+// deferred until it is first accessed.
+type lazyValue struct {
+	once sync.Once
+	raw  json.RawMessage // kept after decoding, so lazy values can be compared without decoding
+	val  any
+}
+
+// value decodes the value on first use and returns it.
+func (l *lazyValue) value() any {
+	l.once.Do(func() {
+		// the document was validated when it was loaded
+		json.Unmarshal(l.raw, &l.val)
+	})
+	return l.val
+}
+
+// MarshalJSON encodes the decoded value.
+func (l *lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.value())
+}
+
+// resolve returns val, decoding it first if it is a lazyValue.
+func resolve(val any) any {
+	if l, ok := val.(*lazyValue); ok {
+		return l.value()
+	}
+	return val
+}
+
+// sameLazy reports whether a and b are lazy values with the same encoding, and therefore equal,
+// without decoding them.
+func sameLazy(a, b any) bool {
+	la, ok := a.(*lazyValue)
+	if !ok {
+		return false
+	}
+	lb, ok := b.(*lazyValue)
+	return ok && (la == lb || bytes.Equal(la.raw, lb.raw))
+}
+
+// resolveAll returns a copy of keyvals with all lazy values decoded.
+func resolveAll(keyvals map[string]any) map[string]any {
+	res := make(map[string]any, len(keyvals))
+	for key, val := range keyvals {
+		res[key] = resolve(val)
+	}
+	return res
+}
+
+// SetLazyDecoding enables or disables lazy decoding for documents loaded afterwards. In lazy mode
+// top level objects and arrays are kept in their encoded form when a document is loaded and only
+// decoded, once, when one of their keys is first accessed. This saves time and memory for large
+// documents of which only a small part is used and does not change the behavior of the
+// configuration otherwise.
+func (c *Configuration) SetLazyDecoding(lazy bool) {
+	c.mu.Lock()
+	c.lazy = lazy
+	c.mu.Unlock()
+}
+
+// decode decodes the document data read from fname in the format registered for its
+// extension, lazily if lazy decoding is enabled and supported by the format, and applies its
+// conditional sections.
+func (c *Configuration) decode(fname string, data []byte) (map[string]any, error) {
+	return c.decodeFormat(formatOf(fname), data)
+}
+
+// decodeFormat is like decode for documents in the format f.
+func (c *Configuration) decodeFormat(f format, data []byte) (map[string]any, error) {
+	c.mu.RLock()
+	lazy := c.lazy
+	c.mu.RUnlock()
+	return decodeDocument(f, data, lazy)
+}
+
+// decodeDocument decodes the document data in the format f, lazily if lazy is set and f supports
+// it, and applies its conditional sections.
+func decodeDocument(f format, data []byte, lazy bool) (map[string]any, error) {
+	decode := f.decode
+	if lazy && f.lazy != nil {
+		decode = f.lazy
+	}
+	keyvals, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return applyConditions(keyvals)
+}
+
+// decodeLazy decodes a JSON document into a key-value map holding lazy values for top level
+// objects and arrays.
+func decodeLazy(data []byte) (map[string]any, error) {
+	if AllowJSONComments {
+		data = stripJSONC(data)
+	}
+	if err := checkDepth(data); err != nil {
+		return nil, err
+	}
+	var raws map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, err
+	}
+	keyvals := make(map[string]any, len(raws))
+	for key, raw := range raws {
+		if len(raw) > 0 && (raw[0] == '{' || raw[0] == '[') {
+			keyvals[key] = &lazyValue{raw: raw}
+			continue
+		}
+		var val any
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return nil, err
+		}
+		keyvals[key] = val
+	}
+	return keyvals, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLazyReloadDoesNotDecode(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"a": {"x": 1}, "b": {"y": 2}}`)
+	c := New()
+	c.SetLazyDecoding(true)
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	var changes []Change
+	c.OnReload(func(chs []Change) { changes = chs })
+
+	if err := os.WriteFile(fname, []byte(`{"a": {"x": 1}, "b": {"y": 3}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Key != "b.y" {
+		t.Fatalf("got changes %v, want one of b.y", changes)
+	}
+	c.mu.RLock()
+	a := c.keyvals["a"].(*lazyValue)
+	c.mu.RUnlock()
+	if a.val != nil {
+		t.Fatal("reload decoded the unchanged lazy value a")
+	}
+}
+
+// benchmarkStartup reads a large document and accesses a few keys of it.
+func benchmarkStartup(b *testing.B, lazy bool) {
+	fname := largeDocument(b, b.TempDir(), 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := New()
+		c.SetLazyDecoding(lazy)
+		if err := c.ReadFile(fname); err != nil {
+			b.Fatal(err)
+		}
+		c.GetStr("service1.host")
+		c.GetInt("service2.port")
+		c.GetBool("service3.enabled")
+	}
+}
+
+func BenchmarkStartupEager(b *testing.B) { benchmarkStartup(b, false) }
+func BenchmarkStartupLazy(b *testing.B)  { benchmarkStartup(b, true) }
+
+// benchmarkReload reloads an unchanged large document with a change callback registered.
+func benchmarkReload(b *testing.B, lazy bool) {
+	fname := largeDocument(b, b.TempDir(), 10000)
+	c := New()
+	c.SetLazyDecoding(lazy)
+	if err := c.ReadFile(fname); err != nil {
+		b.Fatal(err)
+	}
+	c.OnChange("service1.host", func(old, new any) error { return nil })
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Reload(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReloadEager(b *testing.B) { benchmarkReload(b, false) }
+func BenchmarkReloadLazy(b *testing.B)  { benchmarkReload(b, true) }
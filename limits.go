@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// MaxFileSize is the maximum size in bytes of a configuration document. Documents exceeding it
+// are rejected with ErrFileTooLarge. A value of 0 disables the limit.
+var MaxFileSize int64 = 32 << 20
+
+// MaxDepth is the maximum nesting depth of objects and arrays in a configuration document.
+// A value of 0 disables the limit.
+var MaxDepth = 64
+
+// AllowIrregularFiles permits reading configuration files that are not regular files, such as
+// named pipes or devices.
+var AllowIrregularFiles = false
+
+// ErrFileTooLarge is returned when a configuration document exceeds MaxFileSize.
+var ErrFileTooLarge = errors.New("config: config file exceeds limit")
+
+// ErrTooDeep is returned when a configuration document is nested deeper than MaxDepth.
+var ErrTooDeep = errors.New("config: config document exceeds nesting limit")
+
+// readFile reads a configuration file, enforcing AllowIrregularFiles and MaxFileSize.
+func readFile(fname string) ([]byte, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() && !AllowIrregularFiles {
+		return nil, fmt.Errorf("config: %s is not a regular file", fname)
+	}
+	data, err := readLimited(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, fname)
+	}
+	return data, nil
+}
+
+// readFSFile is like readFile for a file of fsys.
+func readFSFile(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() && !AllowIrregularFiles {
+		return nil, fmt.Errorf("config: %s is not a regular file", name)
+	}
+	data, err := readLimited(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, name)
+	}
+	return data, nil
+}
+
+// readLimited reads r to the end, failing with ErrFileTooLarge when more than MaxFileSize bytes
+// are available.
+func readLimited(r io.Reader) ([]byte, error) {
+	if MaxFileSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, MaxFileSize)
+	}
+	return data, nil
+}
+
+// checkDepth fails with ErrTooDeep when objects and arrays in the JSON document data are nested
+// deeper than MaxDepth.
+func checkDepth(data []byte) error {
+	if MaxDepth <= 0 {
+		return nil
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > MaxDepth {
+				return fmt.Errorf("%w of %d", ErrTooDeep, MaxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadFileLimits(t *testing.T) {
+	defer func(size int64, depth int) { MaxFileSize, MaxDepth = size, depth }(MaxFileSize, MaxDepth)
+	MaxFileSize, MaxDepth = 1<<10, 8
+
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth)
+	}
+	tests := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{"at size limit", `{"s": "` + strings.Repeat("x", 1<<10-10) + `"}`, nil},
+		{"oversized", `{"s": "` + strings.Repeat("x", 1<<10) + `"}`, ErrFileTooLarge},
+		{"at depth limit", nested(8), nil},
+		{"too deep", nested(9), ErrTooDeep},
+		{"too deep arrays", `{"a": ` + strings.Repeat("[", 8) + strings.Repeat("]", 8) + `}`, ErrTooDeep},
+		{"brackets in strings", `{"s": "` + strings.Repeat("{[", 20) + `\"{"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fname := writeFile(t, t.TempDir(), "app.json", tt.content)
+			err := New().ReadFile(fname)
+			if tt.err == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadOption configures how Load reads a configuration file.
+type LoadOption func(*loadOptions)
+
+// loadOptions holds the settings applied by LoadOption values.
+type loadOptions struct {
+	format   Format
+	defaults map[string]any
+	strict   bool
+	flat     bool
+	checksum string
+	profile  *string
+	env      bool
+	envPfx   string
+	envOpts  []EnvOption
+	watchCtx context.Context
+	watcher  **Watcher
+}
+
+// WithFormat makes Load decode the file in the registered format f, whatever its extension.
+func WithFormat(f Format) LoadOption {
+	return func(o *loadOptions) {
+		o.format = f
+	}
+}
+
+// WithDefaults registers the default values of the keys of defaults, as SetDefault does for each
+// of them, once the file is read. WithStrict validates the file against them, but they are only
+// registered if Load succeeds in reading and validating the file.
+func WithDefaults(defaults map[string]any) LoadOption {
+	return func(o *loadOptions) {
+		o.defaults = defaults
+	}
+}
+
+// WithStrict makes Load validate the document against the declared keys like ValidateFile
+// before applying it and fail with a *ValidationError on any finding, including keys that are
+// not declared with a default or a description, such as by WithDefaults.
+func WithStrict() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// WithFlatten makes Load flatten the nested objects of the file and its overlay files into dot
+// separated keys, see Flatten, so the configuration has a flat key space. Reload keeps
+// flattening the files. Use WithNested to nest the keys again when writing the configuration.
+func WithFlatten() LoadOption {
+	return func(o *loadOptions) {
+		o.flat = true
+	}
+}
+
+// WithChecksum makes Load read the file only if the SHA-256 of its content matches the hex encoded
+// sha256hex, as ReadFileVerified does. Reload and WatchFile verify the file again. Overlay files
+// and files included with "$include" are not verified.
+func WithChecksum(sha256hex string) LoadOption {
+	return func(o *loadOptions) {
+		o.checksum = strings.ToLower(sha256hex)
+	}
+}
+
+// WithProfile makes Load select the named profile from the "profiles" section of the file once it
+// is read, as SelectProfile does, and fail without changing the configuration if the profile is
+// unknown. WithStrict validates the file with the profile applied.
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = &name
+	}
+}
+
+// WithEnvOverride makes Load enable AutomaticEnv with prefix and opts once the file is read, so
+// environment variables override its values.
+func WithEnvOverride(prefix string, opts ...EnvOption) LoadOption {
+	return func(o *loadOptions) {
+		o.env, o.envPfx, o.envOpts = true, prefix, opts
+	}
+}
+
+// WithWatcher makes Load watch the file with WatchFileCtx once it is read, until ctx is done,
+// and store the watcher in *w if w is not nil.
+func WithWatcher(ctx context.Context, w **Watcher) LoadOption {
+	return func(o *loadOptions) {
+		o.watchCtx, o.watcher = ctx, w
+	}
+}
+
+// Load is like Configuration.Load for the global configuration.
+func Load(path string, opts ...LoadOption) error {
+	return config.Load(path, opts...)
+}
+
+// Load reads the configuration file path and updates c like ReadFile, including layering the
+// overlay file of the selected profile over it. The format is picked by WithFormat or else by the
+// extension of path. If no format is registered for the extension, as for files without one, the
+// format is detected from the content instead: documents starting with "{" are read as JSON, with
+// "<" as XML and others as the first of YAML, TOML and INI they are valid in. Reload and WatchFile
+// keep reading the file in the detected format. The configuration is left unchanged if the file
+// cannot be read, or is invalid with WithStrict, or lacks the profile selected with WithProfile.
+func (c *Configuration) Load(path string, opts ...LoadOption) error {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.checksum != "" {
+		if err := checkChecksum(o.checksum); err != nil {
+			return err
+		}
+	}
+	format := string(o.format)
+	switch {
+	case format != "":
+		if !registered(format) {
+			return fmt.Errorf("config: no format registered for %s", format)
+		}
+	case !registered(filepath.Ext(path)):
+		data, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		if format = sniffFormat(data); format == "" {
+			return fmt.Errorf("config: cannot detect the format of %s", path)
+		}
+	}
+	files := layeredFiles(path, format, c.profileOverlays(path))
+	for i := range files {
+		files[i].flat = o.flat
+	}
+	files[0].checksum = o.checksum
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return err
+	}
+	if o.strict {
+		doc := resolveAll(keyvals)
+		if o.profile != nil {
+			base, profiles, err := splitProfiles(doc)
+			if err != nil {
+				return err
+			}
+			if _, ok := profiles[*o.profile]; ok {
+				doc, _ = applyProfile(base, profiles, *o.profile)
+			} else {
+				doc = base
+			}
+		}
+		if findings := c.findingsWith(doc, o.defaults); len(findings) > 0 {
+			return &ValidationError{Findings: findings}
+		}
+	}
+	if err := c.mergeProfile(keyvals, files, hash, o.profile); err != nil {
+		return err
+	}
+	for key, val := range o.defaults {
+		c.SetDefault(key, val)
+	}
+
+	if o.env {
+		if err := c.AutomaticEnv(o.envPfx, o.envOpts...); err != nil {
+			return err
+		}
+	}
+	if o.watchCtx != nil {
+		w, err := c.WatchFileCtx(o.watchCtx, path)
+		if err != nil {
+			return err
+		}
+		if o.watcher != nil {
+			*o.watcher = w
+		}
+	}
+	return nil
+}
+
+// registered reports whether a format is registered for the extension ext.
+func registered(ext string) bool {
+	_, ok := lookupFormat(ext)
+	return ok
+}
+
+// sniffFormat returns the extension of the registered format the document data is most likely
+// in, or "" if it is valid in none of the candidates.
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	var candidates []string
+	switch {
+	case len(trimmed) == 0 || trimmed[0] == '{':
+		candidates = []string{".json"}
+	case trimmed[0] == '<':
+		candidates = []string{".xml"}
+	default:
+		candidates = []string{".yaml", ".toml", ".ini"}
+	}
+	for _, ext := range candidates {
+		if !registered(ext) {
+			continue
+		}
+		if _, err := formatOf(ext).decode(data); err == nil {
+			return ext
+		}
+	}
+	return ""
+}
+
+// ReadFiles is like Configuration.ReadFiles for the global configuration.
+func ReadFiles(paths ...string) error {
+	return config.ReadFiles(paths...)
+}
+
+// ReadFiles reads the configuration files paths and updates c with their merged content. Later
+// files override earlier ones: objects present in several files are merged key by key, all other
+// values replaced. Each file is decoded in the format registered for its extension. Either all
+// files are applied or, if any of them cannot be read, none. Reload re-reads the files in the same
+// order.
+func (c *Configuration) ReadFiles(paths ...string) error {
+	files := make([]fileSource, len(paths))
+	for i, path := range paths {
+		files[i] = fileSource{name: path, deep: i > 0}
+	}
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return err
+	}
+	return c.merge(keyvals, files, hash)
+}
+
+// ReadDir is like Configuration.ReadDir for the global configuration.
+func ReadDir(dir string) error {
+	return config.ReadDir(dir)
+}
+
+// ReadDir reads the configuration fragments in the directory dir, such as /etc/myapp/conf.d, and
+// merges them into c in lexical order of their names, as ReadFiles does. Only regular files and
+// symlinks to them whose extension has a registered format are read; hidden files and
+// subdirectories are skipped. An empty directory leaves the configuration unchanged. Reload
+// re-reads the fragments found by ReadDir but does not pick up new ones.
+func (c *Configuration) ReadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") || !registered(filepath.Ext(e.Name())) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		// fragments are often symlinks to files managed elsewhere
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return c.ReadFiles(paths...)
+}
+
+// ReadGlob is like Configuration.ReadGlob for the global configuration.
+func ReadGlob(pattern string) error {
+	return config.ReadGlob(pattern)
+}
+
+// ReadGlob reads the configuration files matching the filepath.Match pattern, such as
+// "configs/*.yaml", and merges them into c in lexical order, as ReadFiles does. It fails if the
+// pattern is malformed or matches no files, so a mistyped pattern is not mistaken for an empty set
+// of files.
+func (c *Configuration) ReadGlob(pattern string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("config: %w: %s", err, pattern)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("config: no files match %s", pattern)
+	}
+	sort.Strings(paths)
+	return c.ReadFiles(paths...)
+}
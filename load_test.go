@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadWithDefaults(t *testing.T) {
+	dir := t.TempDir()
+	defaults := map[string]any{"port": 8080, "host": "localhost"}
+
+	c := New()
+	bad := writeFile(t, dir, "bad.json", `{"port": "http", "debug": true}`)
+	var verr *ValidationError
+	if err := c.Load(bad, WithDefaults(defaults), WithStrict()); !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	if len(verr.Findings) != 2 {
+		t.Errorf("got findings %v, want the type of port and the unknown debug", verr.Findings)
+	}
+	if err := c.Load(writeFile(t, dir, "broken.json", `{`), WithDefaults(defaults)); err == nil {
+		t.Fatal("loaded a broken document")
+	}
+	if err := c.Load(writeFile(t, dir, "missing.json", "")+".gone", WithDefaults(defaults)); err == nil {
+		t.Fatal("loaded a missing file")
+	}
+	if _, ok := c.Get("host"); ok {
+		t.Fatal("failed loads registered their defaults")
+	}
+
+	if err := c.Load(writeFile(t, dir, "good.json", `{"port": 9090}`), WithDefaults(defaults), WithStrict()); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("host"); got != "localhost" {
+		t.Errorf("host: got %q, want the default", got)
+	}
+	if got := c.GetInt("port"); got != 9090 {
+		t.Errorf("port: got %d, want 9090", got)
+	}
+}
+
+func TestLoadWithFlatten(t *testing.T) {
+	dir := t.TempDir()
+	fname := writeFile(t, dir, "app.json", `{"db": {"host": "x", "pool": {"size": 5}}, "tags": ["a"], "empty": {}}`)
+	c := New()
+	if err := c.Load(fname, WithFlatten()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"db.host", "db.pool.size", "empty", "tags"}; !slices.Equal(sortedKeys(c.AllSettings()), want) {
+		t.Errorf("got top level keys %v, want %v", sortedKeys(c.AllSettings()), want)
+	}
+	if _, ok := c.Get("db"); ok {
+		t.Error("the nested object is still set")
+	}
+
+	writeFile(t, dir, "app.json", `{"db": {"host": "y"}}`)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"db.host"}; !slices.Equal(sortedKeys(c.AllSettings()), want) {
+		t.Errorf("after Reload: got top level keys %v, want %v", sortedKeys(c.AllSettings()), want)
+	}
+
+	out := filepath.Join(dir, "out.json")
+	c.Set("db.port", 5432)
+	if err := c.WriteFile(out, WithNested()); err != nil {
+		t.Fatal(err)
+	}
+	d := New()
+	if err := d.ReadFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%s %d %d", d.GetStr("db.host"), d.GetInt("db.port"), d.Len()); got != "y 5432 1" {
+		t.Errorf("got written %v, want the keys nested into db", d.AllSettings())
+	}
+
+	c.Set("db", "conflict")
+	if err := c.WriteFile(out, WithNested()); err == nil {
+		t.Error("wrote a key that is also the prefix of other keys")
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// LockTimeout is how long WithFileLock and UpdateFile wait for the lock on a configuration file.
+var LockTimeout = 10 * time.Second
+
+// ErrLocked is returned when the lock on a configuration file is held by another process for
+// longer than LockTimeout.
+var ErrLocked = errors.New("config: config file is locked by another process")
+
+// lockPollInterval is how often a held lock is retried.
+const lockPollInterval = 50 * time.Millisecond
+
+// WithFileLock makes WriteFile hold the advisory lock of the file while writing it. The lock is
+// held on a separate <fname>.lock file, so that it survives the file being replaced, using
+// flock(2) on Unix and LockFileEx on Windows. It only coordinates writers that use the lock
+// themselves. Use UpdateFile to hold the lock over a complete read-modify-write cycle.
+func WithFileLock() WriteOption {
+	return func(o *writeOptions) {
+		o.lock = true
+	}
+}
+
+// UpdateFile locks the configuration file fname as WithFileLock does, loads it into a new
+// Configuration, applies fn and writes the result back atomically with WriteFile and opts.
+// A missing file is treated as empty. Nothing is written if fn returns an error.
+func UpdateFile(fname string, fn func(c *Configuration) error, opts ...WriteOption) error {
+	unlock, err := lockFile(fname, LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c := &Configuration{keyvals: make(map[string]any)}
+	data, err := readFile(fname)
+	switch {
+	case err == nil:
+		// the file is rewritten, so conditional sections must be kept as they are
+		keyvals, err := formatOf(fname).decode(data)
+		if err != nil {
+			return err
+		}
+		if err := c.merge(keyvals, []fileSource{{name: fname, present: true}}, hashOf(data)); err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := fn(c); err != nil {
+		return err
+	}
+
+	o := newWriteOptions(opts)
+	o.lock = false
+	return c.writeFile(fname, o)
+}
+
+// lockFile acquires the advisory lock of fname, waiting up to timeout for other processes to
+// release it, and returns a function releasing the lock.
+func lockFile(fname string, timeout time.Duration) (func() error, error) {
+	f, err := os.OpenFile(fname+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("config: locking %s: %w", fname, err)
+		}
+		if ok {
+			return func() error {
+				err := unlockFile(f)
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+				return err
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLocked, fname)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
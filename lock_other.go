@@ -0,0 +1,21 @@
+//go:build !unix && !windows
+
+package config
+
+import (
+	"errors"
+	"os"
+)
+
+// errLockUnsupported is returned by tryLock on platforms without file locking.
+var errLockUnsupported = errors.New("file locking is not supported on this platform")
+
+// tryLock fails as file locking is not supported on this platform.
+func tryLock(*os.File) (bool, error) {
+	return false, errLockUnsupported
+}
+
+// unlockFile fails as file locking is not supported on this platform.
+func unlockFile(*os.File) error {
+	return errLockUnsupported
+}
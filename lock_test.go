@@ -0,0 +1,71 @@
+//go:build unix || windows
+
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateFileLockTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { LockTimeout = timeout }(LockTimeout)
+	LockTimeout = 200 * time.Millisecond
+
+	fname := filepath.Join(t.TempDir(), "app.json")
+	unlock, err := lockFile(fname, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err = UpdateFile(fname, func(c *Configuration) error {
+		t.Error("fn called without holding the lock")
+		return nil
+	})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("got error %v, want ErrLocked", err)
+	}
+	if d := time.Since(start); d < LockTimeout {
+		t.Fatalf("gave up after %v, before the timeout of %v", d, LockTimeout)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateFile(fname, func(c *Configuration) error {
+		c.Set("n", 1)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateFileContention(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"n": 0}`)
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateFile(fname, func(c *Configuration) error {
+				c.Set("n", c.GetInt("n")+1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("n"); got != n {
+		t.Fatalf("got %d after %d concurrent increments, lost updates", got, n)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// MarshalFrom expands the struct or map obj into configuration values and sets them below the
+// key prefix, or at the top level if prefix is empty, like SetAll. Objects are deep-merged into
+// the objects already set, so keys not present in obj keep their values. Struct fields are named
+// like GetInto matches them, by their "config" tag, or else their "json" tag, or else their name;
+// fields tagged "-", unexported fields and nil pointers are skipped. Values implementing
+// encoding.TextMarshaler and time.Duration values are stored as strings. It fails if obj holds
+// values that cannot be expressed in the configuration, such as channels, or c is frozen.
+func (c *Configuration) MarshalFrom(obj any, prefix string) error {
+	doc, err := c.marshal(obj, prefix)
+	if err != nil {
+		return err
+	}
+	return c.setAll(doc, MergeDeep)
+}
+
+// SetDefaultsFrom is like MarshalFrom but registers the values as defaults with SetDefault, one
+// per key path such as "server.port", so loaded documents override them key by key.
+func (c *Configuration) SetDefaultsFrom(obj any, prefix string) error {
+	doc, err := c.marshal(obj, prefix)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.defaults == nil {
+		c.defaults = make(map[string]any)
+	}
+	for key, val := range Flatten(doc, c.delimiter()) {
+		c.defaults[key] = val
+	}
+	return nil
+}
+
+// marshal encodes obj into an object placed at prefix.
+func (c *Configuration) marshal(obj any, prefix string) (map[string]any, error) {
+	val, err := encodeValue(prefix, reflect.ValueOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	m, ok := val.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: cannot marshal %T, want a struct or map", obj)
+	}
+	if prefix == "" {
+		return m, nil
+	}
+	c.mu.RLock()
+	delim := c.delimiter()
+	c.mu.RUnlock()
+	doc := make(map[string]any)
+	setPath(doc, strings.Split(prefix, delim), m)
+	return doc, nil
+}
+
+// encodeValue encodes v, the value at path, into a configuration value. It returns nil for nil
+// pointers and interfaces.
+func encodeValue(path string, v reflect.Value) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, &KeyError{Key: path, Err: err}
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag, ok := fieldTag(field); ok {
+				if tag == "-" {
+					continue
+				}
+				name = tag
+			}
+			val, err := encodeValue(joinPath(path, name), v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				m[name] = val
+			}
+		}
+		return m, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, &KeyError{Key: path, Err: fmt.Errorf("%w: cannot encode %s", ErrTypeMismatch, v.Type())}
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := iter.Key().String()
+			val, err := encodeValue(joinPath(path, key), iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				m[key] = val
+			}
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		a := make([]any, v.Len())
+		for i := range a {
+			val, err := encodeValue(fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			a[i] = val
+		}
+		return a, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int:
+		return int(v.Int()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, &KeyError{Key: path, Err: fmt.Errorf("%w: %d overflows int64", ErrOutOfRange, v.Uint())}
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return nil, &KeyError{Key: path, Err: fmt.Errorf("%w: cannot encode %s", ErrTypeMismatch, v.Type())}
+}
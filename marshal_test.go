@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalFrom(t *testing.T) {
+	c := New()
+	c.Set("server", map[string]any{"host": "kept", "extra": true})
+	obj := testApp{
+		Name:    "app",
+		Tags:    []string{"a"},
+		Limits:  map[string]int{"conns": 10},
+		Skipped: "x",
+		Server:  testServer{Port: 8080, Timeout: time.Second, Addr: net.ParseIP("10.0.0.1")},
+	}
+	if err := c.MarshalFrom(&obj, "app"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"Name":   "app",
+		"Debug":  false,
+		"Ratio":  0.0,
+		"Tags":   []any{"a"},
+		"Pair":   []any{0, 0},
+		"Limits": map[string]any{"conns": 10},
+		"server": map[string]any{"host": "", "port": int64(8080), "timeout": "1s", "addr": "10.0.0.1"},
+	}
+	if got, _ := c.Get("app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// objects are merged into the values already set
+	if err := c.MarshalFrom(map[string]any{"server": map[string]any{"port": 9090}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("server.host") + " " + c.GetStr("server.port"); got != "kept 9090" || !c.GetBool("server.extra") {
+		t.Errorf("got %q, want the keys not marshaled kept", got)
+	}
+
+	// the result decodes into an equal value
+	var back testApp
+	if err := c.UnmarshalKey("app", &back); err != nil {
+		t.Fatal(err)
+	}
+	obj.Skipped = ""
+	if !reflect.DeepEqual(back, obj) {
+		t.Errorf("got %+v after decoding, want %+v", back, obj)
+	}
+
+	var kerr *KeyError
+	if err := c.MarshalFrom(map[string]any{"ch": make(chan int)}, "x"); !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &kerr) || kerr.Key != "x.ch" {
+		t.Errorf("got %v, want a *KeyError for x.ch wrapping ErrTypeMismatch", err)
+	}
+	if err := c.MarshalFrom(42, ""); err == nil {
+		t.Error("marshaled a number")
+	}
+	c.Freeze()
+	if err := c.MarshalFrom(obj, ""); !errors.Is(err, ErrFrozen) {
+		t.Errorf("got %v on a frozen configuration, want ErrFrozen", err)
+	}
+}
+
+func TestSetDefaultsFrom(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"server": {"port": 9090}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDefaultsFrom(testServer{Host: "localhost", Port: 8080, Timeout: time.Minute}, "server"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%s %d %s", c.GetStr("server.host"), c.GetInt("server.port"), c.GetStr("server.timeout")); got != "localhost 9090 1m0s" {
+		t.Errorf("got %q, want the loaded port to override the defaults key by key", got)
+	}
+	c.Delete("server")
+	if got := c.GetInt("server.port"); got != 8080 {
+		t.Errorf("got port %d, want the default", got)
+	}
+}
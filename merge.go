@@ -0,0 +1,95 @@
+package config
+
+import (
+	"maps"
+	"slices"
+)
+
+// MergeStrategy selects how Merge combines the values of two configurations.
+type MergeStrategy int
+
+const (
+	// MergeShallow replaces the top level keys as a whole, like reading another file does.
+	MergeShallow MergeStrategy = iota
+	// MergeDeep merges objects key by key, descending into nested objects, and replaces arrays.
+	MergeDeep
+	// MergeAppend merges objects like MergeDeep and appends the elements of arrays.
+	MergeAppend
+	// MergeIndex merges objects like MergeDeep and arrays element by element, merging the
+	// elements at the same index and keeping the elements beyond the end of the shorter array.
+	MergeIndex
+)
+
+// Merge merges the effective values of other into the loaded values of c using strategy. Values
+// that are not both objects or both arrays are replaced by the values of other. The merged values
+// behave like loaded ones: environment variables, flags and values set with Set still override
+// them and Reload replaces them with the content of the files c was read from. The change is
+// applied atomically and notified to change callbacks.
+func (c *Configuration) Merge(other *Configuration, strategy MergeStrategy) {
+	other.mu.RLock()
+	src := deepCopyMap(other.keyvals)
+	other.mu.RUnlock()
+
+	c.mu.Lock()
+	if err := c.checkFrozen(""); err != nil {
+		c.mu.Unlock()
+		c.rejected(err)
+		return
+	}
+	old := c.keyvals
+	keyvals := maps.Clone(c.fileKeyvals())
+	if keyvals == nil {
+		keyvals = make(map[string]any, len(src))
+	}
+	for key, val := range src {
+		keyvals[key] = mergeValue(keyvals[key], val, strategy)
+	}
+	c.setFileKeyvals(keyvals)
+	new := c.keyvals
+	c.record()
+	c.mu.Unlock()
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+}
+
+// mergeValue returns src merged over dst using strategy. It does not modify dst, but the result
+// may share values with both.
+func mergeValue(dst, src any, strategy MergeStrategy) any {
+	if strategy == MergeShallow {
+		return src
+	}
+	switch s := src.(type) {
+	case map[string]any:
+		d, ok := resolve(dst).(map[string]any)
+		if !ok {
+			return src
+		}
+		res := maps.Clone(d)
+		for key, val := range s {
+			res[key] = mergeValue(res[key], val, strategy)
+		}
+		return res
+	case []any:
+		d, ok := resolve(dst).([]any)
+		if !ok {
+			return src
+		}
+		switch strategy {
+		case MergeAppend:
+			return append(slices.Clone(d), s...)
+		case MergeIndex:
+			res := slices.Clone(d)
+			for i, val := range s {
+				if i < len(res) {
+					res[i] = mergeValue(res[i], val, strategy)
+				} else {
+					res = append(res, val)
+				}
+			}
+			return res
+		}
+	}
+	return src
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeStrategies(t *testing.T) {
+	base := `{"server": {"host": "a", "tls": {"on": true}}, "hosts": [{"name": "x", "port": 1}, {"name": "y"}], "tags": ["a", "b"]}`
+	over := map[string]any{
+		"server": map[string]any{"tls": map[string]any{"cert": "c.pem"}},
+		"hosts":  []any{map[string]any{"port": 2}},
+		"tags":   []any{"c"},
+		"name":   "new",
+	}
+	for _, tt := range []struct {
+		strategy MergeStrategy
+		want     map[string]any
+	}{
+		{MergeShallow, map[string]any{
+			"server": map[string]any{"tls": map[string]any{"cert": "c.pem"}},
+			"hosts":  []any{map[string]any{"port": 2}},
+			"tags":   []any{"c"},
+			"name":   "new",
+		}},
+		{MergeDeep, map[string]any{
+			"server": map[string]any{"host": "a", "tls": map[string]any{"on": true, "cert": "c.pem"}},
+			"hosts":  []any{map[string]any{"port": 2}},
+			"tags":   []any{"c"},
+			"name":   "new",
+		}},
+		{MergeAppend, map[string]any{
+			"server": map[string]any{"host": "a", "tls": map[string]any{"on": true, "cert": "c.pem"}},
+			"hosts":  []any{map[string]any{"name": "x", "port": 1.0}, map[string]any{"name": "y"}, map[string]any{"port": 2}},
+			"tags":   []any{"a", "b", "c"},
+			"name":   "new",
+		}},
+		{MergeIndex, map[string]any{
+			"server": map[string]any{"host": "a", "tls": map[string]any{"on": true, "cert": "c.pem"}},
+			"hosts":  []any{map[string]any{"name": "x", "port": 2}, map[string]any{"name": "y"}},
+			"tags":   []any{"c", "b"},
+			"name":   "new",
+		}},
+	} {
+		c := New()
+		if err := c.LoadString(base); err != nil {
+			t.Fatal(err)
+		}
+		other := New()
+		other.SetAll(over)
+		c.Merge(other, tt.strategy)
+		if got := c.AllSettings(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("strategy %d: got %v, want %v", tt.strategy, got, tt.want)
+		}
+		if l, _ := c.Origin("server"); l != LayerFiles {
+			t.Errorf("strategy %d: got origin %v, want the merged values in the files layer", tt.strategy, l)
+		}
+		other.Set("tags", []any{"changed"})
+		if got := c.GetStr("tags.0"); got == "changed" {
+			t.Errorf("strategy %d: the merged values are shared with other", tt.strategy)
+		}
+	}
+
+	// arrays of more elements extend the shorter one
+	c := New()
+	if err := c.LoadString(`{"tags": ["a"]}`); err != nil {
+		t.Fatal(err)
+	}
+	other := New()
+	other.Set("tags", []any{"x", "y"})
+	c.Merge(other, MergeIndex)
+	if got, _ := c.Get("tags"); !reflect.DeepEqual(got, []any{"x", "y"}) {
+		t.Errorf("got tags %v", got)
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Namespace gives a library or component access to the keys below a prefix, either reserved
+// with RegisterNamespace or taken without reservation with Sub. The keys passed to its methods
+// are relative to the prefix, so key "timeout" of namespace "db" is the configuration key
+// "db.timeout". A Namespace holds no values itself and always reflects its configuration.
+type Namespace struct {
+	c      *Configuration
+	prefix string
+}
+
+// RegisterNamespace reserves the keys below prefix for the caller and returns a handle to them.
+// It fails if prefix equals, contains or is contained in a namespace registered before, such
+// as "db" and "db.pool"; prefixes are compared by whole segments separated by the key delimiter,
+// so "db" and "dbx" do not overlap.
+func (c *Configuration) RegisterNamespace(prefix string) (Namespace, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delim := c.delimiter()
+	prefix = strings.Trim(prefix, delim)
+	if prefix == "" {
+		return Namespace{}, errors.New("config: empty namespace prefix")
+	}
+	for ns := range c.namespaces {
+		if overlaps(ns, prefix, delim) {
+			return Namespace{}, fmt.Errorf("config: namespace %q overlaps registered namespace %q", prefix, ns)
+		}
+	}
+	if c.namespaces == nil {
+		c.namespaces = make(map[string]bool)
+	}
+	c.namespaces[prefix] = true
+	return Namespace{c: c, prefix: prefix}, nil
+}
+
+// Sub returns a view of the keys below prefix without reserving them, so components can be
+// handed only their section of the configuration: Get("host") of c.Sub("database") returns the
+// value of "database.host" in c. The empty prefix gives a view of the whole configuration.
+func (c *Configuration) Sub(prefix string) Namespace {
+	c.mu.RLock()
+	delim := c.delimiter()
+	c.mu.RUnlock()
+	return Namespace{c: c, prefix: strings.Trim(prefix, delim)}
+}
+
+// ListNamespaces returns the registered namespace prefixes in lexical order.
+func (c *Configuration) ListNamespaces() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return sortedKeys(c.namespaces)
+}
+
+// SetNamespaceHook registers fn to be called whenever Set or Delete change a key inside a
+// registered namespace without going through its Namespace handle. fn receives the key and the
+// prefix of the namespace and is called after the change was applied. A nil fn removes the hook.
+func (c *Configuration) SetNamespaceHook(fn func(key, prefix string)) {
+	c.mu.Lock()
+	c.nsHook = fn
+	c.mu.Unlock()
+}
+
+// Prefix returns the prefix of the namespace.
+func (ns Namespace) Prefix() string {
+	return ns.prefix
+}
+
+// Sub returns a view of the keys below prefix inside the namespace, see Configuration.Sub.
+func (ns Namespace) Sub(prefix string) Namespace {
+	sub := ns.c.Sub(prefix)
+	return Namespace{c: ns.c, prefix: ns.key(sub.prefix)}
+}
+
+// Get retrieves a value from the namespace by key, falling back to its default.
+func (ns Namespace) Get(key string) (any, bool) {
+	return ns.c.Get(ns.key(key))
+}
+
+// GetShared is like Get but returns objects and arrays without copying them; see
+// Configuration.GetShared.
+func (ns Namespace) GetShared(key string) (any, bool) {
+	return ns.c.GetShared(ns.key(key))
+}
+
+// Exists checks if a key exists in the namespace.
+func (ns Namespace) Exists(key string) bool {
+	return ns.c.Exists(ns.key(key))
+}
+
+// Set sets a value in the namespace by key.
+func (ns Namespace) Set(key string, val any) {
+	if err := ns.c.set(ns.key(key), val); err != nil {
+		ns.c.rejected(err)
+	}
+}
+
+// Delete removes a key from the namespace.
+func (ns Namespace) Delete(key string) {
+	if err := ns.c.delete(ns.key(key)); err != nil {
+		ns.c.rejected(err)
+	}
+}
+
+// GetStr retrieves a string value from the namespace by key.
+func (ns Namespace) GetStr(key string) string {
+	return Get[string](ns.c, ns.key(key))
+}
+
+// GetInt retrieves an int value from the namespace by key.
+func (ns Namespace) GetInt(key string) int {
+	return Get[int](ns.c, ns.key(key))
+}
+
+// GetInt64 retrieves an int64 value from the namespace by key.
+func (ns Namespace) GetInt64(key string) int64 {
+	return Get[int64](ns.c, ns.key(key))
+}
+
+// GetFloat64 retrieves a float64 value from the namespace by key.
+func (ns Namespace) GetFloat64(key string) float64 {
+	return Get[float64](ns.c, ns.key(key))
+}
+
+// GetBool retrieves a bool value from the namespace by key.
+func (ns Namespace) GetBool(key string) bool {
+	return Get[bool](ns.c, ns.key(key))
+}
+
+// key returns the configuration key of key in the namespace, joined to the prefix with the key
+// delimiter.
+func (ns Namespace) key(key string) string {
+	if ns.prefix == "" {
+		return key
+	}
+	if key == "" {
+		return ns.prefix
+	}
+	ns.c.mu.RLock()
+	delim := ns.c.delimiter()
+	ns.c.mu.RUnlock()
+	return ns.prefix + delim + key
+}
+
+// namespaceOf returns the prefix of the registered namespace containing key, or "" if there is
+// none. The caller must hold c.mu.
+func (c *Configuration) namespaceOf(key string) string {
+	for ns := range c.namespaces {
+		if key == ns || strings.HasPrefix(key, ns+c.delimiter()) {
+			return ns
+		}
+	}
+	return ""
+}
+
+// overlaps reports whether one of the namespace prefixes a and b, whose segments are separated
+// by delim, contains the other.
+func overlaps(a, b, delim string) bool {
+	return a == b || strings.HasPrefix(a, b+delim) || strings.HasPrefix(b, a+delim)
+}
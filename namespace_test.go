@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestRegisterNamespaceKeyDelimiter(t *testing.T) {
+	c := New()
+	c.SetKeyDelimiter("/")
+	ns, err := c.RegisterNamespace("/db/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.Prefix() != "db" {
+		t.Fatalf("got prefix %q, want db", ns.Prefix())
+	}
+	if _, err := c.RegisterNamespace("db/pool"); err == nil {
+		t.Fatal("overlapping namespace registered")
+	}
+	if _, err := c.RegisterNamespace("db.pool"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSub(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"database": {"host": "localhost", "pool": {"size": 10}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("database.port", 5432)
+	db := c.Sub(".database.")
+	if db.Prefix() != "database" {
+		t.Fatalf("got prefix %q", db.Prefix())
+	}
+	if got := db.GetStr("host") + " " + db.GetStr("port"); got != "localhost 5432" {
+		t.Errorf("got %q, want the values and defaults below the prefix", got)
+	}
+	pool := db.Sub("pool")
+	if got := pool.GetInt("size"); got != 10 {
+		t.Errorf("pool.size: got %d", got)
+	}
+	if got, _ := db.Get(""); got.(map[string]any)["host"] != "localhost" {
+		t.Errorf("empty key: got %v, want the whole section", got)
+	}
+
+	// the view reflects and changes its configuration
+	db.Set("user", "app")
+	if got := c.GetStr("database.user"); got != "app" {
+		t.Errorf("database.user: got %q", got)
+	}
+	c.Set("database.host", "db.internal")
+	if got := db.GetStr("host"); got != "db.internal" {
+		t.Errorf("host after Set: got %q", got)
+	}
+	db.Delete("user")
+	if db.Exists("user") || c.Exists("database.user") {
+		t.Error("user still set after Delete")
+	}
+	if got := c.Sub("").GetStr("database.host"); got != "db.internal" {
+		t.Errorf("empty prefix: got %q", got)
+	}
+	// Sub does not reserve the prefix
+	if _, err := c.RegisterNamespace("database"); err != nil {
+		t.Errorf("Sub reserved its prefix: %v", err)
+	}
+}
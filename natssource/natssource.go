@@ -0,0 +1,126 @@
+// Package natssource provides a configuration provider reading the keys of a NATS JetStream
+// key-value bucket and watching them for changes:
+//
+//	js, err := jetstream.New(nc)
+//	kv, err := js.KeyValue(ctx, "myapp")
+//	src := natssource.New(kv, "")
+//	err = config.Config().StartWatch(ctx, src)
+//
+// Keys are split at "." into nested configuration keys, so the key "db.host" sets "host" inside
+// the object "db". Values are decoded with config.ParseValue.
+package natssource
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// Source is a config.Provider reading the keys of a JetStream key-value bucket.
+type Source struct {
+	kv     jetstream.KeyValue
+	prefix string
+}
+
+// New returns a Source reading the keys of kv below the dot separated prefix, or all keys if
+// prefix is empty.
+func New(kv jetstream.KeyValue, prefix string) *Source {
+	return &Source{kv: kv, prefix: strings.TrimSuffix(prefix, ".")}
+}
+
+// Name identifies the source by its bucket and prefix.
+func (s *Source) Name() string {
+	name := "nats:" + s.kv.Bucket()
+	if s.prefix != "" {
+		name += "/" + s.prefix
+	}
+	return name
+}
+
+// Load reads the current values of the keys.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	w, err := s.watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+	kvs := make(map[string]any)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case entry, ok := <-w.Updates():
+			if !ok {
+				return nil, errors.New("watch closed")
+			}
+			// the watcher sends nil once it delivered the current values
+			if entry == nil {
+				return config.Nest(kvs, ".")
+			}
+			s.apply(kvs, entry)
+		}
+	}
+}
+
+// Watch reports the document once the current values were read and after every change of the
+// keys afterwards.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	w, err := s.watch(ctx)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	kvs := make(map[string]any)
+	initial := true
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry, ok := <-w.Updates():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("watch closed")
+			}
+			if entry == nil {
+				initial = false
+			} else {
+				s.apply(kvs, entry)
+			}
+			if initial {
+				continue
+			}
+			doc, err := config.Nest(kvs, ".")
+			if err != nil {
+				return err
+			}
+			fn(doc)
+		}
+	}
+}
+
+// watch watches the keys below the prefix.
+func (s *Source) watch(ctx context.Context) (jetstream.KeyWatcher, error) {
+	if s.prefix == "" {
+		return s.kv.WatchAll(ctx)
+	}
+	return s.kv.Watch(ctx, s.prefix+".>")
+}
+
+// apply records the value of entry in kvs, keyed by its key relative to the prefix.
+func (s *Source) apply(kvs map[string]any, entry jetstream.KeyValueEntry) {
+	key := entry.Key()
+	if s.prefix != "" {
+		key = strings.TrimPrefix(key, s.prefix+".")
+	}
+	if entry.Operation() != jetstream.KeyValuePut {
+		delete(kvs, key)
+		return
+	}
+	kvs[key] = config.ParseValue(entry.Value())
+}
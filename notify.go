@@ -0,0 +1,356 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// ChangeKind classifies a Change.
+type ChangeKind int
+
+const (
+	// Added marks keys that were not set before the change.
+	Added ChangeKind = iota + 1
+	// Removed marks keys that are not set after the change.
+	Removed
+	// Modified marks keys whose value changed.
+	Modified
+)
+
+// String returns the lower case name of the kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change describes the change of the value of a key.
+//
+// Changes are reported at the finest granularity of nested objects: changing a value inside an
+// object yields a change of the path of that value, joined with the key delimiter, and adding or
+// removing an object yields a change for every value inside it (or for the object itself, if it
+// is empty).
+// Arrays are compared and reported as a whole, as are values whose type changes between an
+// object and any other type. Every callback receives its own copies of the values, which it may
+// keep and modify.
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	Old  any // value before the change, nil if Kind is Added
+	New  any // value after the change, nil if Kind is Removed
+}
+
+// CallbackPanic is passed to the callback error handler when a change callback panics.
+type CallbackPanic struct {
+	Key   string // key the callback was registered for, empty for OnReload callbacks
+	Value any    // value passed to panic
+	Stack []byte // stack trace of the panicking goroutine
+}
+
+// Error describes the panic.
+func (p *CallbackPanic) Error() string {
+	if p.Key == "" {
+		return fmt.Sprintf("config: reload callback panicked: %v", p.Value)
+	}
+	return fmt.Sprintf("config: change callback for %q panicked: %v", p.Key, p.Value)
+}
+
+// SubscribeOption configures how OnChange and OnReload deliver changes to a callback.
+type SubscribeOption func(*subscriber)
+
+// Async delivers changes to the callback on a separate goroutine through a queue holding up to
+// size notifications, so a slow callback cannot block writers or other callbacks. Notifications
+// arriving while the queue is full are dropped and reported to the callback error handler.
+func Async(size int) SubscribeOption {
+	return func(s *subscriber) {
+		s.queue = make(chan event, size)
+	}
+}
+
+// subscriber is a callback registered with OnChange or OnReload.
+type subscriber struct {
+	key      string
+	onChange func(old, new any) error // set for OnChange callbacks
+	onReload func(changes []Change)   // set for OnReload callbacks
+	all      bool                     // onReload receives all changes, not only loads
+	queue    chan event               // nil for synchronous delivery
+	closed   bool                     // set once unregistered
+}
+
+// event is a notification for a single subscriber.
+type event struct {
+	old, new any      // values of the subscribed key for OnChange callbacks
+	changes  []Change // changes for OnReload callbacks
+}
+
+// delta holds the top level values of the changed keys before and after an update of the
+// configuration, from which notify computes the changes.
+type delta struct {
+	old, new map[string]any
+	reload   bool // the update loaded a configuration document
+}
+
+// newDelta returns the delta between the top level values old and new.
+func newDelta(old, new map[string]any, reload bool) delta {
+	return delta{old: old, new: new, reload: reload}
+}
+
+// OnChange registers fn to be called with the old and new value whenever the value of key is set,
+// deleted, loaded or reloaded to a different value, including changes of values nested inside
+// it. The values are copies fn may keep and modify. By default fn is called synchronously by the
+// goroutine that changed the configuration, after the change was applied; see Async. Panics and
+// errors of fn are passed to the callback error handler and never affect other callbacks. The
+// returned function unregisters fn.
+func (c *Configuration) OnChange(key string, fn func(old, new any) error, opts ...SubscribeOption) func() {
+	return c.subscribe(&subscriber{key: key, onChange: fn}, opts)
+}
+
+// OnReload registers fn to be called with all changes whenever a configuration document is
+// loaded or reloaded, such as by ReadFile, Reload or a Watcher, and changes the configuration.
+// Callbacks registered with OnChange are driven by the same changes. Delivery and error handling
+// work as for OnChange. The returned function unregisters fn.
+func (c *Configuration) OnReload(fn func(changes []Change), opts ...SubscribeOption) func() {
+	return c.subscribe(&subscriber{onReload: fn}, opts)
+}
+
+// subscribe registers s and returns a function unregistering it.
+func (c *Configuration) subscribe(s *subscriber, opts []SubscribeOption) func() {
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.queue != nil {
+		go func() {
+			for ev := range s.queue {
+				c.invoke(s, ev)
+			}
+		}()
+	}
+
+	c.subsMu.Lock()
+	c.subs = append(c.subs, s)
+	c.subsMu.Unlock()
+
+	return func() {
+		c.subsMu.Lock()
+		defer c.subsMu.Unlock()
+		for i, sub := range c.subs {
+			if sub == s {
+				c.subs = append(c.subs[:i:i], c.subs[i+1:]...)
+				s.closed = true
+				if s.queue != nil {
+					close(s.queue)
+				}
+				return
+			}
+		}
+	}
+}
+
+// SetCallbackErrorHandler registers fn to receive the errors returned by change callbacks, their
+// panics as *CallbackPanic and notifications dropped from full Async queues. By default they are
+// logged with the standard logger.
+func (c *Configuration) SetCallbackErrorHandler(fn func(err error)) {
+	c.subsMu.Lock()
+	c.errHandler = fn
+	c.subsMu.Unlock()
+}
+
+// hasSubscribers reports whether any callbacks are registered, so updates can skip computing
+// deltas nobody receives.
+func (c *Configuration) hasSubscribers() bool {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	return len(c.subs) > 0
+}
+
+// notify delivers d to the registered callbacks. It must not be called with c.mu held.
+func (c *Configuration) notify(d delta) {
+	c.mu.RLock()
+	delim := c.delimiter()
+	c.mu.RUnlock()
+	changes := diffValues("", d.old, d.new, delim)
+	if len(changes) == 0 {
+		return
+	}
+	c.subsMu.Lock()
+	subs := c.subs
+	c.subsMu.Unlock()
+
+	for _, s := range subs {
+		var ev event
+		switch {
+		case s.onReload != nil:
+			if !d.reload && !s.all {
+				continue
+			}
+			ev.changes = copyChanges(changes)
+		case affects(changes, s.key, delim):
+			old, _ := valueAt(d.old, s.key, delim)
+			new, _ := valueAt(d.new, s.key, delim)
+			ev.old, ev.new = deepCopy(old), deepCopy(new)
+		default:
+			continue
+		}
+		c.deliver(s, ev)
+	}
+}
+
+// copyChanges returns a copy of changes whose values share no maps or slices with the
+// configuration.
+func copyChanges(changes []Change) []Change {
+	res := make([]Change, len(changes))
+	for i, ch := range changes {
+		res[i] = Change{Key: ch.Key, Kind: ch.Kind, Old: deepCopy(ch.Old), New: deepCopy(ch.New)}
+	}
+	return res
+}
+
+// deliver passes ev to s, either directly or through its queue.
+func (c *Configuration) deliver(s *subscriber, ev event) {
+	if s.queue == nil {
+		c.invoke(s, ev)
+		return
+	}
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		go c.handleCallbackError(fmt.Errorf("config: callback queue for %q is full, notification dropped", s.key))
+	}
+}
+
+// invoke calls the callback of s with ev, recovering from panics.
+func (c *Configuration) invoke(s *subscriber, ev event) {
+	defer func() {
+		if v := recover(); v != nil {
+			c.handleCallbackError(&CallbackPanic{Key: s.key, Value: v, Stack: debug.Stack()})
+		}
+	}()
+	if s.onReload != nil {
+		s.onReload(ev.changes)
+		return
+	}
+	if err := s.onChange(ev.old, ev.new); err != nil {
+		c.handleCallbackError(fmt.Errorf("config: change callback for %q: %w", s.key, err))
+	}
+}
+
+// handleCallbackError passes err to the callback error handler.
+func (c *Configuration) handleCallbackError(err error) {
+	c.subsMu.Lock()
+	handler := c.errHandler
+	c.subsMu.Unlock()
+	if handler == nil {
+		log.Print(err)
+		return
+	}
+	handler(err)
+}
+
+// affects reports whether any of changes concerns key, a value nested inside it or an object
+// containing it, with key paths separated by delim.
+func affects(changes []Change, key, delim string) bool {
+	for _, ch := range changes {
+		if ch.Key == key || strings.HasPrefix(ch.Key, key+delim) || strings.HasPrefix(key, ch.Key+delim) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueAt returns the value of the key path in m, whose segments are separated by delim.
+func valueAt(m map[string]any, key, delim string) (any, bool) {
+	if val, ok := m[key]; ok {
+		return resolve(val), true
+	}
+	for i := strings.Index(key, delim); i >= 0; {
+		if sub, ok := resolve(m[key[:i]]).(map[string]any); ok {
+			if val, ok := valueAt(sub, key[i+len(delim):], delim); ok {
+				return val, true
+			}
+		}
+		j := strings.Index(key[i+len(delim):], delim)
+		if j < 0 {
+			break
+		}
+		i += len(delim) + j
+	}
+	return nil, false
+}
+
+// Diff returns the changes from the effective values of a to those of b in lexical order of their
+// keys, such as for logging what a reload changed. Changes are reported for the values nested in
+// objects, like to OnReload callbacks, with keys such as "server.port" joined with the key
+// delimiter of a. The values of the changes are copies that may be kept and modified.
+func Diff(a, b *Configuration) []Change {
+	a.mu.RLock()
+	old := deepCopyMap(a.keyvals)
+	delim := a.delimiter()
+	a.mu.RUnlock()
+	b.mu.RLock()
+	new := deepCopyMap(b.keyvals)
+	b.mu.RUnlock()
+	return diffValues("", old, new, delim)
+}
+
+// diffValues returns the changes between the values in old and new, whose keys are nested below
+// prefix, joining nested keys with delim.
+func diffValues(prefix string, old, new map[string]any, delim string) []Change {
+	keys := make(map[string]bool, len(old)+len(new))
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range new {
+		keys[key] = true
+	}
+
+	var changes []Change
+	for _, k := range sortedKeys(keys) {
+		key := prefix + k
+		o, hadOld := old[k]
+		n, hasNew := new[k]
+		if hadOld && hasNew && sameLazy(o, n) {
+			continue
+		}
+		o, n = resolve(o), resolve(n)
+		om, oldIsMap := o.(map[string]any)
+		nm, newIsMap := n.(map[string]any)
+		switch {
+		case (oldIsMap || !hadOld) && (newIsMap || !hasNew):
+			sub := diffValues(key+delim, om, nm, delim)
+			if len(sub) == 0 && hadOld != hasNew {
+				sub = []Change{change(key, o, hadOld, n, hasNew)}
+			}
+			changes = append(changes, sub...)
+		case hadOld != hasNew || !reflect.DeepEqual(o, n):
+			changes = append(changes, change(key, o, hadOld, n, hasNew))
+		}
+	}
+	return changes
+}
+
+// change returns the change of key from o to n.
+func change(key string, o any, hadOld bool, n any, hasNew bool) Change {
+	switch {
+	case !hadOld:
+		return Change{Key: key, Kind: Added, New: n}
+	case !hasNew:
+		return Change{Key: key, Kind: Removed, Old: o}
+	default:
+		return Change{Key: key, Kind: Modified, Old: o, New: n}
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestOnChangePanicIsolation(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		var opts []SubscribeOption
+		name := "sync"
+		if async {
+			opts = []SubscribeOption{Async(16)}
+			name = "async"
+		}
+		t.Run(name, func(t *testing.T) {
+			c := New()
+			var mu sync.Mutex
+			var panics int
+			var got []any
+			c.SetCallbackErrorHandler(func(err error) {
+				var p *CallbackPanic
+				if !errors.As(err, &p) {
+					t.Errorf("unexpected callback error: %v", err)
+				}
+				mu.Lock()
+				panics++
+				mu.Unlock()
+			})
+			c.OnChange("port", func(old, new any) error { panic("boom") }, opts...)
+			c.OnChange("port", func(old, new any) error {
+				mu.Lock()
+				got = append(got, new)
+				mu.Unlock()
+				return nil
+			}, opts...)
+
+			for port := 1; port <= 3; port++ {
+				c.Set("port", port)
+			}
+			eventually(t, "all notifications", func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(got) == 3 && panics == 3
+			})
+			for i, val := range got {
+				if val != i+1 {
+					t.Fatalf("notification %d: got %v, want %d", i, val, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestCallbackValuesAreCopies(t *testing.T) {
+	c := New()
+	c.Set("server", map[string]any{"port": 1, "tags": []any{"a"}})
+
+	corrupt := func(val any) {
+		if m, ok := val.(map[string]any); ok {
+			m["port"] = -1
+			m["tags"].([]any)[0] = "x"
+		}
+	}
+	c.OnChange("server", func(old, new any) error {
+		corrupt(old)
+		corrupt(new)
+		return nil
+	})
+	c.OnReload(func(changes []Change) {
+		for _, ch := range changes {
+			corrupt(ch.Old)
+			corrupt(ch.New)
+		}
+	}, func(s *subscriber) { s.all = true })
+	var got any
+	c.OnChange("server", func(old, new any) error {
+		got = new
+		return nil
+	})
+
+	c.Set("server", map[string]any{"port": 2, "tags": []any{"b"}})
+	if port := c.GetInt("server.port"); port != 2 {
+		t.Fatalf("callback changed the port to %d", port)
+	}
+	if tag := c.GetStr("server.tags.0"); tag != "b" {
+		t.Fatalf("callback changed the tag to %q", tag)
+	}
+	if m := got.(map[string]any); m["port"] != 2 || m["tags"].([]any)[0] != "b" {
+		t.Fatalf("callback received %v modified by another callback", m)
+	}
+}
+
+func TestOnChangeKeyDelimiter(t *testing.T) {
+	c := New()
+	c.SetKeyDelimiter("/")
+	c.Set("server", map[string]any{"port": 1, "host": "a.b"})
+
+	var got []any
+	c.OnChange("server/port", func(old, new any) error {
+		got = append(got, new)
+		return nil
+	})
+	var changes []Change
+	c.OnReload(func(chs []Change) { changes = chs }, func(s *subscriber) { s.all = true })
+
+	c.Set("server", map[string]any{"port": 2, "host": "a.b"})
+	if len(got) != 1 || got[0] != 2 {
+		t.Fatalf("got notifications %v, want [2]", got)
+	}
+	if len(changes) != 1 || changes[0].Key != "server/port" {
+		t.Fatalf("got changes %v, want one of server/port", changes)
+	}
+}
+
+func TestChangeGranularity(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string // changes as "key kind old new"
+	}{
+		{"unchanged", `{"a": {"b": 1}, "l": [1]}`, `{"a": {"b": 1}, "l": [1]}`, nil},
+		{"nested value", `{"a": {"b": 1, "c": 1}}`, `{"a": {"b": 2, "c": 1}}`, []string{"a.b modified 1 2"}},
+		{"added object", `{}`, `{"a": {"b": 1, "c": {"d": 2}}}`, []string{"a.b added <nil> 1", "a.c.d added <nil> 2"}},
+		{"removed object", `{"a": {"b": 1, "c": {"d": 2}}}`, `{}`, []string{"a.b removed 1 <nil>", "a.c.d removed 2 <nil>"}},
+		{"added empty object", `{}`, `{"a": {"b": {}}}`, []string{"a.b added <nil> map[]"}},
+		{"removed empty object", `{"a": {}}`, `{}`, []string{"a removed map[] <nil>"}},
+		{"array", `{"l": [1, 2, 3]}`, `{"l": [1, 4, 3]}`, []string{"l modified [1 2 3] [1 4 3]"}},
+		{"array of objects", `{"l": [{"a": 1}]}`, `{"l": [{"a": 2}]}`, []string{"l modified [map[a:1]] [map[a:2]]"}},
+		{"object to scalar", `{"a": {"b": 1}}`, `{"a": 5}`, []string{"a modified map[b:1] 5"}},
+		{"scalar to object", `{"a": 5}`, `{"a": {"b": 1}}`, []string{"a modified 5 map[b:1]"}},
+		{"scalar to array", `{"a": 5}`, `{"a": [5]}`, []string{"a modified 5 [5]"}},
+	}
+	format := func(changes []Change) []string {
+		var res []string
+		for _, ch := range changes {
+			res = append(res, fmt.Sprint(ch.Key, " ", ch.Kind, " ", ch.Old, " ", ch.New))
+		}
+		sort.Strings(res)
+		return res
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := New(), New()
+			if err := a.LoadBytes([]byte(tt.old)); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.LoadBytes([]byte(tt.new)); err != nil {
+				t.Fatal(err)
+			}
+			if got := format(Diff(a, b)); !slices.Equal(got, tt.want) {
+				t.Errorf("Diff: got %q, want %q", got, tt.want)
+			}
+
+			fname := writeFile(t, t.TempDir(), "app.json", tt.old)
+			c := New()
+			if err := c.ReadFile(fname); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			reloads := 0
+			c.OnReload(func(changes []Change) {
+				reloads++
+				got = format(changes)
+			})
+			writeFile(t, filepath.Dir(fname), "app.json", tt.new)
+			if err := c.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("OnReload: got %q, want %q", got, tt.want)
+			}
+			if tt.want == nil && reloads != 0 {
+				t.Errorf("OnReload called %d times without changes", reloads)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, b := New(), New()
+	if err := a.LoadBytes([]byte(`{"z": 1, "server": {"port": 80, "hosts": ["a"]}, "gone": true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LoadBytes([]byte(`{"z": 1, "server": {"port": 80, "hosts": ["a"]}, "added": "x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	b.Set("server.port", 8080)
+	a.SetKeyDelimiter("/")
+
+	changes := Diff(a, b)
+	var keys []string
+	for _, ch := range changes {
+		keys = append(keys, ch.Key+" "+ch.Kind.String())
+	}
+	if want := []string{"added added", "gone removed", "server/port modified"}; !slices.Equal(keys, want) {
+		t.Fatalf("got changes %q, want %q in lexical order", keys, want)
+	}
+	if ch := changes[2]; !valuesEqual(ch.Old, 80) || !valuesEqual(ch.New, 8080) {
+		t.Errorf("got %v to %v, want the effective values", ch.Old, ch.New)
+	}
+	if got := Diff(a, a); len(got) != 0 {
+		t.Errorf("got changes %v between a and itself", got)
+	}
+
+	c := New()
+	c.Set("server", map[string]any{"hosts": []any{"a"}})
+	changes = Diff(New(), c)
+	changes[0].New.([]any)[0] = "changed"
+	if got := c.GetStr("server.hosts.0"); got != "a" {
+		t.Errorf("changing the value of a change changed the configuration: got %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProfileEnv is the environment variable naming the profile whose overlay file ReadFile and Load
+// layer over a configuration file, unless a profile was set with SetFileProfile.
+var ProfileEnv = "CONFIG_PROFILE"
+
+// SetFileProfile is like Configuration.SetFileProfile for the global configuration.
+func SetFileProfile(name string) {
+	config.SetFileProfile(name)
+}
+
+// SetFileProfile sets the profile, such as "dev", "staging" or "prod", whose overlay file ReadFile,
+// Load and LoadWithLocalOverride deep-merge over the configuration files they read, overriding
+// ProfileEnv. The overlay file of "config.json" for profile "prod" is "config.prod.json"; a missing
+// overlay file is not an error. The overlay is recorded as a source of the configuration, so Reload
+// and WatchFile pick up changes of it including it appearing or disappearing later. Setting the
+// empty name falls back to ProfileEnv again.
+func (c *Configuration) SetFileProfile(name string) {
+	c.mu.Lock()
+	c.fileProfile = name
+	c.mu.Unlock()
+}
+
+// LoadWithLocalOverride is like Configuration.LoadWithLocalOverride for the global configuration.
+func LoadWithLocalOverride(fname string) error {
+	return config.LoadWithLocalOverride(fname)
+}
+
+// LoadWithLocalOverride reads the configuration file fname like Load and deep-merges the local
+// override file next to it over it, if that file exists. The local override file of "config.json"
+// is "config.local.json"; it is applied after the profile overlay file, if any. All files are
+// recorded as sources of the configuration, so Reload and WatchFile pick up changes of any of them,
+// including overlay files appearing or disappearing later; Sources reports which were present.
+func (c *Configuration) LoadWithLocalOverride(fname string) error {
+	return c.readLayered(fname, "", append(c.profileOverlays(fname), overlayName(fname, "local"))...)
+}
+
+// readLayered reads the configuration file fname, decoded in the format with the extension
+// format if not empty, deep-merges the existing overlay files over it and merges the result into
+// the configuration.
+func (c *Configuration) readLayered(fname, format string, overlays ...string) error {
+	files := layeredFiles(fname, format, overlays)
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return err
+	}
+	return c.merge(keyvals, files, hash)
+}
+
+// layeredFiles returns the sources of the configuration file fname and its optional overlay
+// files, all decoded in the format with the extension format if not empty.
+func layeredFiles(fname, format string, overlays []string) []fileSource {
+	files := []fileSource{{name: fname, format: format}}
+	for _, overlay := range overlays {
+		files = append(files, fileSource{name: overlay, optional: true, deep: true, format: format})
+	}
+	return files
+}
+
+// profileOverlays returns the name of the profile overlay file of fname in a slice, or nothing
+// if no profile is selected.
+func (c *Configuration) profileOverlays(fname string) []string {
+	c.mu.RLock()
+	profile := c.fileProfile
+	c.mu.RUnlock()
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile == "" {
+		return nil
+	}
+	return []string{overlayName(fname, profile)}
+}
+
+// overlayName returns the name of the overlay file of fname tagged tag, such as
+// "config.local.json" for "config.json" and "local".
+func overlayName(fname, tag string) string {
+	ext := filepath.Ext(fname)
+	return strings.TrimSuffix(fname, ext) + "." + tag + ext
+}
@@ -0,0 +1,18 @@
+package config
+
+import "maps"
+
+// WithOverrides returns a configuration with the values and defaults of c in which the keys of
+// overrides are set like with SetAll, for request or test scoped tweaks. c is not changed, and
+// changes of c made afterwards are not visible in the returned configuration, nor the other way
+// round. Values not overridden are shared with c rather than copied, which keeps deriving a
+// configuration cheap; like the results of GetShared they must not be modified in place. The
+// returned configuration has no files, bindings or callbacks and is not frozen.
+func (c *Configuration) WithOverrides(overrides map[string]any) *Configuration {
+	c.mu.RLock()
+	v := c.shadow()
+	v.defaults = maps.Clone(c.defaults)
+	c.mu.RUnlock()
+	v.SetAll(overrides)
+	return v
+}
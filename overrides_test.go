@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestWithOverrides(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"server": {"host": "a", "port": 80}, "name": "app"}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("timeout", 5)
+	c.Freeze()
+
+	v := c.WithOverrides(map[string]any{"server.port": 8080, "debug": true})
+	if got := v.GetInt("server.port"); got != 8080 {
+		t.Errorf("got port %d, want the override", got)
+	}
+	if got := v.GetStr("server.host") + v.GetStr("name"); got != "aapp" {
+		t.Errorf("got %q, want the values of c that are not overridden", got)
+	}
+	if got := v.GetInt("timeout"); got != 5 {
+		t.Errorf("got timeout %d, want the default of c", got)
+	}
+	if c.GetInt("server.port") != 80 || c.Exists("debug") {
+		t.Error("the overrides changed c")
+	}
+	if v.Frozen() {
+		t.Error("the view of a frozen configuration is frozen")
+	}
+
+	// changes of the view do not reach c
+	v.Set("server.host", "b")
+	v.Delete("name")
+	v.SetDefault("timeout", 10)
+	if got := c.GetStr("server.host") + c.GetStr("name"); got != "aapp" || c.GetInt("timeout") != 5 {
+		t.Errorf("changing the view changed c: got %q", got)
+	}
+
+	// changes of c do not reach the view
+	d := New()
+	d.Set("server", map[string]any{"host": "a"})
+	w := d.WithOverrides(nil)
+	d.Set("server.host", "changed")
+	d.Set("added", 1)
+	d.SetDefault("other", 1)
+	if got := w.GetStr("server.host"); got != "a" || w.Exists("added") || w.GetInt("other") != 0 {
+		t.Errorf("changing c changed the view: got host %q", got)
+	}
+}
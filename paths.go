@@ -0,0 +1,173 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetKeyDelimiter sets the delimiter separating the segments of key paths, "." by default. The
+// getters resolve a key that is not set as a whole by descending into nested objects and arrays
+// along its segments, so "server.http.port" returns the value of "port" in the object "http" of
+// the object "server" and "upstreams.2.host", also written "upstreams[2].host", the value of
+// "host" in the third element of the array "upstreams". The empty delimiter restores the default.
+func (c *Configuration) SetKeyDelimiter(delim string) {
+	c.mu.Lock()
+	c.keyDelim = delim
+	c.mu.Unlock()
+}
+
+// delimiter returns the delimiter of key paths. The caller must hold c.mu.
+func (c *Configuration) delimiter() string {
+	if c.keyDelim == "" {
+		return "."
+	}
+	return c.keyDelim
+}
+
+// findPath returns the value of key in m. Keys that are not present as a whole are split at
+// delim and looked up segment by segment in nested objects and arrays, where keys holding delim
+// themselves are found as well, such as "a.b" in the object "x" for the path "x.a.b". Array
+// indexes may also be given in brackets, as in "x[1]".
+func findPath(m map[string]any, key, delim string) (any, bool) {
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+	return findIn(m, indexPath(key, delim), delim)
+}
+
+// findIn returns the value at path below the object or array node.
+func findIn(node any, path, delim string) (any, bool) {
+	switch n := resolve(node).(type) {
+	case map[string]any:
+		if val, ok := n[path]; ok {
+			return resolve(val), true
+		}
+		for i := strings.Index(path, delim); i >= 0; {
+			if sub, ok := n[path[:i]]; ok {
+				if val, ok := findIn(sub, path[i+len(delim):], delim); ok {
+					return val, true
+				}
+			}
+			j := strings.Index(path[i+len(delim):], delim)
+			if j < 0 {
+				break
+			}
+			i += len(delim) + j
+		}
+	case []any:
+		head, rest, nested := strings.Cut(path, delim)
+		i, err := strconv.Atoi(head)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, false
+		}
+		if !nested {
+			return resolve(n[i]), true
+		}
+		return findIn(n[i], rest, delim)
+	}
+	return nil, false
+}
+
+// indexPath rewrites the bracketed array indexes of path as segments separated by delim, so
+// "a[1].b" becomes "a.1.b" with the delimiter ".".
+func indexPath(path, delim string) string {
+	if !strings.Contains(path, "[") {
+		return path
+	}
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(path, '[')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(path[open:], ']')
+		if end < 0 {
+			break
+		}
+		index := path[open+1 : open+end]
+		if _, err := strconv.ParseUint(index, 10, 0); err != nil {
+			b.WriteString(path[:open+end+1])
+			path = path[open+end+1:]
+			continue
+		}
+		b.WriteString(path[:open])
+		if open > 0 || b.Len() > 0 {
+			b.WriteString(delim)
+		}
+		b.WriteString(index)
+		path = path[open+end+1:]
+	}
+	b.WriteString(path)
+	return b.String()
+}
+
+// setIn sets the value at path below the object or array node, which must not be shared, and
+// reports whether it could. Missing objects along path are created, while array indexes must be
+// within the bounds of their arrays.
+func setIn(node any, path, delim string, val any) bool {
+	switch n := node.(type) {
+	case map[string]any:
+		if _, ok := n[path]; ok {
+			n[path] = val
+			return true
+		}
+		for i := strings.Index(path, delim); i >= 0; {
+			if sub, ok := n[path[:i]]; ok && isComposite(sub) {
+				return setIn(sub, path[i+len(delim):], delim, val)
+			}
+			j := strings.Index(path[i+len(delim):], delim)
+			if j < 0 {
+				break
+			}
+			i += len(delim) + j
+		}
+		parts := strings.Split(path, delim)
+		setPath(n, parts, val)
+		return true
+	case []any:
+		head, rest, nested := strings.Cut(path, delim)
+		i, err := strconv.Atoi(head)
+		if err != nil || i < 0 || i >= len(n) {
+			return false
+		}
+		if !nested {
+			n[i] = val
+			return true
+		}
+		if !isComposite(n[i]) {
+			return false
+		}
+		return setIn(n[i], rest, delim, val)
+	}
+	return false
+}
+
+// deleteIn removes the value at path below the object or array node and reports whether it was
+// present. Array elements are not removed, only values inside them.
+func deleteIn(node any, path, delim string) bool {
+	switch n := node.(type) {
+	case map[string]any:
+		if _, ok := n[path]; ok {
+			delete(n, path)
+			return true
+		}
+		for i := strings.Index(path, delim); i >= 0; {
+			if sub, ok := n[path[:i]]; ok && isComposite(sub) && deleteIn(sub, path[i+len(delim):], delim) {
+				return true
+			}
+			j := strings.Index(path[i+len(delim):], delim)
+			if j < 0 {
+				break
+			}
+			i += len(delim) + j
+		}
+	case []any:
+		head, rest, nested := strings.Cut(path, delim)
+		i, err := strconv.Atoi(head)
+		if err != nil || i < 0 || i >= len(n) || !nested || !isComposite(n[i]) {
+			return false
+		}
+		return deleteIn(n[i], rest, delim)
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNestedPaths(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{
+		"server": {"http": {"port": 8080, "host": "localhost"}, "a.b": "dotted"},
+		"x.y": "flat"
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"server.http.port": 8080,
+		"server.a.b":       "dotted",
+		"x.y":              "flat",
+	} {
+		if got, _ := c.Get(key); !valuesEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", key, got, want)
+		}
+	}
+	if c.Exists("server.http.missing") || c.Exists("server.http.port.x") {
+		t.Error("found a missing path")
+	}
+
+	c.Set("server.http.port", 9090)
+	if got := c.GetInt("server.http.port"); got != 9090 {
+		t.Errorf("after Set: got %d", got)
+	}
+	if got := c.GetStr("server.http.host"); got != "localhost" {
+		t.Errorf("Set replaced the siblings: got host %q", got)
+	}
+	if l, _ := c.Origin("server"); l != LayerSet {
+		t.Errorf("got origin %v, want the set layer to hold the object", l)
+	}
+
+	var removed []any
+	c.OnChange("server.http.host", func(old, new any) error {
+		removed = append(removed, old, new)
+		return nil
+	})
+	c.Delete("server.http.host")
+	if c.Exists("server.http.host") {
+		t.Error("server.http.host still set after Delete")
+	}
+	if got := c.GetInt("server.http.port"); got != 9090 {
+		t.Errorf("Delete removed the siblings: got port %d", got)
+	}
+	if want := []any{"localhost", nil}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("got changes %v, want %v", removed, want)
+	}
+	c.Delete("server.a.b")
+	if c.Exists("server.a.b") {
+		t.Error("server.a.b still set after Delete")
+	}
+
+	d := New()
+	d.SetKeyDelimiter("/")
+	if err := d.LoadBytes([]byte(`{"server": {"http.port": 80}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.GetInt("server/http.port"); got != 80 {
+		t.Errorf("custom delimiter: got %d", got)
+	}
+	d.Delete("server/http.port")
+	if d.Exists("server/http.port") || !d.Exists("server") {
+		t.Error("custom delimiter: Delete did not remove the nested key only")
+	}
+}
+
+func TestArrayPaths(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"upstreams": [{"host": "a"}, {"host": "b"}, {"host": "c", "ports": [80, 443]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"upstreams[2].host":     "c",
+		"upstreams.2.host":      "c",
+		"upstreams[2].ports[1]": 443,
+		"upstreams.2.ports.1":   443,
+		"upstreams[0]":          map[string]any{"host": "a"},
+	} {
+		if got, _ := c.Get(key); !valuesEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", key, got, want)
+		}
+	}
+	for _, key := range []string{"upstreams[3].host", "upstreams.-1.host", "upstreams[x]", "upstreams.0.host.x"} {
+		if c.Exists(key) {
+			t.Errorf("%s: found a missing element", key)
+		}
+	}
+
+	c.Set("upstreams[1].host", "B")
+	if got := c.GetStr("upstreams.1.host") + c.GetStr("upstreams.0.host"); got != "Ba" {
+		t.Errorf("after Set: got %q", got)
+	}
+	c.Set("upstreams[2].ports.0", 8080)
+	if got := c.GetInt("upstreams[2].ports[0]"); got != 8080 {
+		t.Errorf("after Set in a nested array: got %d", got)
+	}
+	// indexes beyond the end are not appended but set as keys of their own
+	c.Set("upstreams[5].host", "x")
+	if got, _ := c.Get("upstreams"); len(got.([]any)) != 3 {
+		t.Errorf("Set appended to the array: got %v", got)
+	}
+}
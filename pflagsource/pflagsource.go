@@ -0,0 +1,133 @@
+// Package pflagsource binds the flags of a pflag.FlagSet, such as those of a cobra command, to
+// configuration keys:
+//
+//	root := &cobra.Command{Use: "myapp", RunE: run}
+//	root.PersistentFlags().String("db-url", "", "database URL")
+//	pflagsource.BindCommand(config.Config(), root)
+//
+// Flag names are translated to keys by replacing dashes with dots, so the flag --db-url sets
+// "url" inside the object "db". Only flags changed on the command line go to the flags layer of
+// the configuration, see config.LayerFlags: they override the loaded documents and environment
+// variables, while keys of unchanged flags keep the values of the lower layers.
+//
+// Binding replaces the whole flags layer, so do not combine it with Configuration.BindFlag.
+package pflagsource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// Option configures how flags are bound.
+type Option func(*binder)
+
+// binder holds the settings of Bind.
+type binder struct {
+	key  func(name string) string
+	keys map[string]string
+}
+
+// WithKeyFunc sets the function translating flag names to keys, replacing the default that
+// turns dashes into dots.
+func WithKeyFunc(key func(name string) string) Option {
+	return func(b *binder) {
+		b.key = key
+	}
+}
+
+// WithKey binds the flag name to key instead of the translated flag name.
+func WithKey(name, key string) Option {
+	return func(b *binder) {
+		b.keys[name] = key
+	}
+}
+
+// Key returns the default key of the flag name: the name with dashes replaced by dots.
+func Key(name string) string {
+	return strings.ReplaceAll(name, "-", ".")
+}
+
+// Bind replaces the flags layer of c with the values of the flags of fs changed on the command
+// line. Call it after parsing fs, and again after parsing another command line.
+func Bind(c *config.Configuration, fs *pflag.FlagSet, opts ...Option) error {
+	b := &binder{key: Key, keys: make(map[string]string)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	flat := make(map[string]any)
+	fs.Visit(func(f *pflag.Flag) {
+		key, ok := b.keys[f.Name]
+		if !ok {
+			key = b.key(f.Name)
+		}
+		if key != "" {
+			flat[key] = value(f)
+		}
+	})
+	keyvals, err := config.Nest(flat, ".")
+	if err != nil {
+		return fmt.Errorf("%w in command line flags", err)
+	}
+	return c.SetLayer(config.LayerFlags, keyvals)
+}
+
+// BindCommand binds the flags of cmd and its subcommands to c once cobra has parsed the command
+// line: the flags of the executed command, including the persistent flags of its parents, are
+// bound before its persistent pre-run hook and any hook already set on cmd.
+func BindCommand(c *config.Configuration, cmd *cobra.Command, opts ...Option) {
+	preRunE, preRun := cmd.PersistentPreRunE, cmd.PersistentPreRun
+	cmd.PersistentPreRun = nil
+	cmd.PersistentPreRunE = func(run *cobra.Command, args []string) error {
+		if err := Bind(c, run.Flags(), opts...); err != nil {
+			return err
+		}
+		switch {
+		case preRunE != nil:
+			return preRunE(run, args)
+		case preRun != nil:
+			preRun(run, args)
+		}
+		return nil
+	}
+}
+
+// value returns the value of f as one of the types the typed getters of config convert, falling
+// back to its string form. Slices become []any like decoded JSON arrays.
+func value(f *pflag.Flag) any {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		vals := sv.GetSlice()
+		arr := make([]any, len(vals))
+		for i, v := range vals {
+			arr[i] = v
+		}
+		return arr
+	}
+	s := f.Value.String()
+	switch f.Value.Type() {
+	case "string":
+		return s
+	case "bool":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "count":
+		if v, err := strconv.Atoi(s); err == nil {
+			return v
+		}
+	case "int64", "uint32":
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v
+		}
+	case "float32", "float64":
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+	}
+	return s
+}
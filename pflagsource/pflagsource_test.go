@@ -0,0 +1,93 @@
+package pflagsource
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+func TestBind(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.String("db-url", "", "")
+	fs.Int("port", 8080, "")
+	fs.Bool("debug", false, "")
+	fs.Float64("ratio", 0, "")
+	fs.StringSlice("tags", nil, "")
+	fs.String("log-level", "info", "")
+	fs.String("unchanged", "x", "")
+	args := []string{"--db-url", "postgres://flag", "--port", "9090", "--debug", "--ratio", "0.5", "--tags", "a,b", "--log-level", "debug"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.New()
+	if err := c.LoadBytes([]byte(`{"db": {"url": "postgres://file", "user": "app"}, "unchanged": "file"}`)); err != nil {
+		t.Fatal(err)
+	}
+	err := Bind(c, fs, WithKey("log-level", "log_level"), WithKeyFunc(func(name string) string {
+		if name == "ratio" {
+			return ""
+		}
+		return Key(name)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"db":        map[string]any{"url": "postgres://flag"},
+		"port":      9090,
+		"debug":     true,
+		"tags":      []any{"a", "b"},
+		"log_level": "debug",
+	}
+	if got := c.LayerValues(config.LayerFlags); !reflect.DeepEqual(got, want) {
+		t.Errorf("got flags layer %v, want %v", got, want)
+	}
+	if got := c.GetStr("db.user") + " " + c.GetStr("unchanged"); got != "app file" {
+		t.Errorf("got %q, want the values of unchanged flags from the file", got)
+	}
+
+	conflicting := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	conflicting.String("db", "", "")
+	conflicting.String("db-url", "", "")
+	if err := conflicting.Parse([]string{"--db", "x", "--db-url", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Bind(c, conflicting); err == nil || !strings.Contains(err.Error(), "command line flags") {
+		t.Errorf("got %v, want a conflict error", err)
+	}
+}
+
+func TestBindCommand(t *testing.T) {
+	c := config.New()
+	var seen string
+	var hookRan bool
+	root := &cobra.Command{
+		Use:              "app",
+		PersistentPreRun: func(*cobra.Command, []string) { hookRan = true },
+	}
+	root.PersistentFlags().String("db-url", "", "")
+	sub := &cobra.Command{
+		Use: "serve",
+		Run: func(*cobra.Command, []string) { seen = c.GetStr("db.url") + " " + c.GetStr("addr") },
+	}
+	sub.Flags().String("addr", ":80", "")
+	root.AddCommand(sub)
+	BindCommand(c, root)
+
+	root.SetArgs([]string{"serve", "--db-url", "postgres://flag", "--addr", ":8080"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if seen != "postgres://flag :8080" {
+		t.Errorf("command saw %q, want the bound flags", seen)
+	}
+	if !hookRan {
+		t.Error("the persistent pre-run hook of the command did not run")
+	}
+}
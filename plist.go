@@ -0,0 +1,371 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+func init() {
+	RegisterFormat(".plist", decodePlist, nil)
+}
+
+// maxPlistObjects limits the number of objects decoded from a binary property list, whose
+// objects may be referenced many times to expand into huge documents.
+const maxPlistObjects = 1 << 20
+
+// plistEpoch is the reference date of binary property list dates.
+var plistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// decodePlist decodes a macOS property list in the XML or binary format into a key-value map.
+// The top level object must be a dictionary. Integers are kept as int64, dates are converted to
+// RFC 3339 strings and data to base64 strings, so the result holds the same kinds of values as
+// a decoded JSON document otherwise. Property lists can only be read.
+func decodePlist(data []byte) (map[string]any, error) {
+	var doc any
+	var err error
+	if bytes.HasPrefix(data, []byte("bplist00")) {
+		doc, err = decodeBinaryPlist(data)
+	} else {
+		doc, err = decodeXMLPlist(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyvals, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: property list must hold a dictionary, got %s", typeName(doc))
+	}
+	return keyvals, nil
+}
+
+// decodeXMLPlist decodes the top level object of an XML property list.
+func decodeXMLPlist(data []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	inPlist := false
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("config: property list has no object")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "plist" && !inPlist {
+			inPlist = true
+			continue
+		}
+		return decodeXMLPlistValue(d, start, 1)
+	}
+}
+
+// decodeXMLPlistValue decodes the object started by start at nesting depth depth.
+func decodeXMLPlistValue(d *xml.Decoder, start xml.StartElement, depth int) (any, error) {
+	if MaxDepth > 0 && depth > MaxDepth {
+		return nil, fmt.Errorf("%w of %d", ErrTooDeep, MaxDepth)
+	}
+	switch start.Name.Local {
+	case "dict":
+		m := make(map[string]any)
+		var key *string
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					var k string
+					if err := d.DecodeElement(&k, &t); err != nil {
+						return nil, err
+					}
+					key = &k
+					continue
+				}
+				if key == nil {
+					return nil, fmt.Errorf("config: property list dictionary value <%s> without key", t.Name.Local)
+				}
+				val, err := decodeXMLPlistValue(d, t, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				m[*key], key = val, nil
+			case xml.EndElement:
+				return m, nil
+			}
+		}
+	case "array":
+		arr := []any{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				val, err := decodeXMLPlistValue(d, t, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			case xml.EndElement:
+				return arr, nil
+			}
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid property list integer %q", text)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid property list real %q", text)
+		}
+		return f, nil
+	case "date":
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid property list date %q", text)
+		}
+		return t.Format(time.RFC3339Nano), nil
+	case "data":
+		raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid property list data: %w", err)
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	default:
+		return nil, fmt.Errorf("config: unknown property list element <%s>", start.Name.Local)
+	}
+}
+
+// binaryPlist holds the object table of a binary property list being decoded.
+type binaryPlist struct {
+	data    []byte
+	offsets []uint64 // offsets of the objects by reference
+	refSize int
+	active  map[uint64]bool // objects being decoded, to detect cycles
+	decoded int
+}
+
+// decodeBinaryPlist decodes the top level object of a binary property list.
+func decodeBinaryPlist(data []byte) (any, error) {
+	if len(data) < 8+32 {
+		return nil, errors.New("config: truncated binary property list")
+	}
+	trailer := data[len(data)-32:]
+	offsetSize, refSize := int(trailer[6]), int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 || top >= numObjects ||
+		tableOffset > uint64(len(data)) || numObjects > (uint64(len(data))-tableOffset)/uint64(offsetSize) {
+		return nil, errors.New("config: invalid binary property list trailer")
+	}
+
+	p := &binaryPlist{data: data, offsets: make([]uint64, numObjects), refSize: refSize, active: make(map[uint64]bool)}
+	for i := range p.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		p.offsets[i] = readUint(data[start : start+uint64(offsetSize)])
+	}
+	return p.object(top, 1)
+}
+
+// object decodes the object with reference ref at nesting depth depth.
+func (p *binaryPlist) object(ref uint64, depth int) (any, error) {
+	if MaxDepth > 0 && depth > MaxDepth {
+		return nil, fmt.Errorf("%w of %d", ErrTooDeep, MaxDepth)
+	}
+	if ref >= uint64(len(p.offsets)) || p.offsets[ref] >= uint64(len(p.data)) {
+		return nil, errors.New("config: invalid binary property list object reference")
+	}
+	if p.active[ref] {
+		return nil, errors.New("config: binary property list contains a cycle")
+	}
+	if p.decoded++; p.decoded > maxPlistObjects {
+		return nil, errors.New("config: binary property list has too many objects")
+	}
+	p.active[ref] = true
+	defer delete(p.active, ref)
+
+	off := p.offsets[ref]
+	marker := p.data[off]
+	kind, info := marker>>4, int(marker&0x0f)
+	off++
+
+	switch kind {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		case 0x00:
+			return nil, nil
+		}
+	case 0x1:
+		b, err := p.bytes(off, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		// 16 byte integers hold values beyond int64 in their high bytes, which are dropped
+		return int64(readUint(b[len(b)-min(len(b), 8):])), nil
+	case 0x2:
+		b, err := p.bytes(off, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(b) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+	case 0x3:
+		b, err := p.bytes(off, 8)
+		if err != nil {
+			return nil, err
+		}
+		secs := math.Float64frombits(binary.BigEndian.Uint64(b))
+		return plistEpoch.Add(time.Duration(secs * float64(time.Second))).Format(time.RFC3339Nano), nil
+	case 0x4, 0x5, 0x6:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x6 {
+			b, err := p.bytes(off, 2*n)
+			if err != nil {
+				return nil, err
+			}
+			units := make([]uint16, n)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+			return string(utf16.Decode(units)), nil
+		}
+		b, err := p.bytes(off, n)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x4 {
+			return base64.StdEncoding.EncodeToString(b), nil
+		}
+		return string(b), nil
+	case 0xa:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.bytes(off, n*p.refSize)
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = p.object(readUint(refs[i*p.refSize:(i+1)*p.refSize]), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case 0xd:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.bytes(off, 2*n*p.refSize)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			key, err := p.object(readUint(refs[i*p.refSize:(i+1)*p.refSize]), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, errors.New("config: binary property list dictionary key is not a string")
+			}
+			j := n + i
+			if m[k], err = p.object(readUint(refs[j*p.refSize:(j+1)*p.refSize]), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("config: unsupported binary property list object 0x%02x", marker)
+}
+
+// count returns the element count encoded in the low nibble info of an object marker whose
+// remaining bytes start at off, together with the offset of the object content.
+func (p *binaryPlist) count(info int, off uint64) (int, uint64, error) {
+	if info != 0x0f {
+		return info, off, nil
+	}
+	// larger counts follow the marker as an integer object
+	b, err := p.bytes(off, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]>>4 != 0x1 {
+		return 0, 0, errors.New("config: invalid binary property list count")
+	}
+	size := 1 << (b[0] & 0x0f)
+	nb, err := p.bytes(off+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := readUint(nb)
+	if n > uint64(len(p.data)) {
+		return 0, 0, errors.New("config: invalid binary property list count")
+	}
+	return int(n), off + 1 + uint64(size), nil
+}
+
+// bytes returns the n bytes at off, failing if they exceed the data.
+func (p *binaryPlist) bytes(off uint64, n int) ([]byte, error) {
+	if n < 0 || off > uint64(len(p.data)) || uint64(n) > uint64(len(p.data))-off {
+		return nil, errors.New("config: truncated binary property list")
+	}
+	return p.data[off : off+uint64(n)], nil
+}
+
+// readUint decodes a big-endian unsigned integer of up to 8 bytes.
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// ReadDefaults is like Configuration.ReadDefaults for the global configuration.
+func ReadDefaults(domain string) error {
+	return config.ReadDefaults(domain)
+}
+
+// ReadDefaults reads the preferences of the macOS defaults domain, such as "com.example.agent", and
+// updates c with them. The domain is exported with the defaults command, so the values reflect the
+// preferences of the current user including those not yet written to disk by cfprefsd. Reload does
+// not re-read the domain.
+func (c *Configuration) ReadDefaults(domain string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("defaults", "export", domain, "-")
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("config: exporting defaults domain %s: %w: %s", domain, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	keyvals, err := decodePlist(data)
+	if err != nil {
+		return fmt.Errorf("config: defaults domain %s: %w", domain, err)
+	}
+	return c.merge(keyvals, nil, hashOf(data))
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeXMLPlist(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>name</key><string>app</string>
+	<key>port</key><integer>8080</integer>
+	<key>mask</key><integer>0x10</integer>
+	<key>ratio</key><real>1.5</real>
+	<key>on</key><true/>
+	<key>off</key><false/>
+	<key>since</key><date>2026-01-02T03:04:05Z</date>
+	<key>blob</key><data>
+		aGVs
+		bG8=
+	</data>
+	<key>tags</key><array><string>a</string><dict><key>b</key><string>c</string></dict></array>
+	<key>empty</key><array/>
+</dict>
+</plist>`
+	got, err := decodePlist([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name": "app", "port": int64(8080), "mask": int64(16), "ratio": 1.5, "on": true, "off": false,
+		"since": "2026-01-02T03:04:05Z", "blob": "aGVsbG8=",
+		"tags":  []any{"a", map[string]any{"b": "c"}},
+		"empty": []any{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeXMLPlistErrors(t *testing.T) {
+	for _, tt := range []struct{ doc, err string }{
+		{`<plist><array/></plist>`, "must hold a dictionary, got array"},
+		{`<plist></plist>`, "has no object"},
+		{`<plist><dict><string>x</string></dict></plist>`, "value <string> without key"},
+		{`<plist><dict><key>a</key><integer>x</integer></dict></plist>`, `invalid property list integer "x"`},
+		{`<plist><dict><key>a</key><date>yesterday</date></dict></plist>`, "invalid property list date"},
+		{`<plist><dict><key>a</key><set/></dict></plist>`, "unknown property list element <set>"},
+		{`<plist><dict><key>a</key>`, "EOF"},
+	} {
+		if _, err := decodePlist([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+}
+
+// binaryPlistOf returns a binary property list of the encoded objects with top as the top level
+// object, using one byte offsets and references.
+func binaryPlistOf(top int, objects ...[]byte) []byte {
+	data := []byte("bplist00")
+	var offsets []byte
+	for _, obj := range objects {
+		offsets = append(offsets, byte(len(data)))
+		data = append(data, obj...)
+	}
+	tableOffset := len(data)
+	data = append(data, offsets...)
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[16:], uint64(top))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	return append(data, trailer...)
+}
+
+// bplistString encodes an ASCII string object.
+func bplistString(s string) []byte {
+	return append([]byte{0x50 | byte(len(s))}, s...)
+}
+
+// bplistFloat encodes an object of marker with the 8 byte float f, such as a real or a date.
+func bplistFloat(marker byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{marker}, math.Float64bits(f))
+}
+
+func TestDecodeBinaryPlist(t *testing.T) {
+	data := binaryPlistOf(0,
+		[]byte{0xd6, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, // dictionary of the keys 1-6 and values 7-12
+		bplistString("name"), bplistString("port"), bplistString("on"),
+		bplistString("ratio"), bplistString("since"), bplistString("tags"),
+		[]byte{0x60 | 3, 0, 'a', 0, 'p', 0x00, 0xe9}, // UTF-16 string "apé"
+		[]byte{0x11, 0x1f, 0x90},                     // 2 byte integer 8080
+		[]byte{0x09},
+		bplistFloat(0x23, 1.5),
+		bplistFloat(0x33, 86400),
+		[]byte{0xa2, 13, 14}, // array of the objects 13 and 14
+		bplistString("a"),
+		[]byte{0x40 | 2, 'h', 'i'},
+	)
+	got, err := decodePlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name": "apé", "port": int64(8080), "on": true, "ratio": 1.5,
+		"since": "2001-01-02T00:00:00Z", "tags": []any{"a", "aGk="},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeBinaryPlistErrors(t *testing.T) {
+	beyond := binaryPlistOf(0, []byte{0xd1, 1, 2}, bplistString("k"), bplistString("v"))
+	beyond[len(beyond)-32-3+2] = 60 // offset of the value beyond the data
+	for _, tt := range []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"short", []byte("bplist00"), "truncated binary property list"},
+		{"trailer", binaryPlistOf(5, []byte{0x09}), "invalid binary property list trailer"},
+		{"not a dictionary", binaryPlistOf(0, []byte{0xa0}), "must hold a dictionary"},
+		{"cycle", binaryPlistOf(0, []byte{0xd1, 1, 0}, bplistString("k")), "contains a cycle"},
+		{"reference", binaryPlistOf(0, []byte{0xd1, 1, 7}, bplistString("k")), "invalid binary property list object reference"},
+		{"object beyond data", beyond, "invalid binary property list object reference"},
+		{"string beyond data", binaryPlistOf(0, []byte{0xd1, 1, 1}, []byte{0x5f, 0x10, 0xff}), "invalid binary property list count"},
+		{"key type", binaryPlistOf(0, []byte{0xd1, 1, 1}, []byte{0x09}), "dictionary key is not a string"},
+		{"unsupported", binaryPlistOf(0, []byte{0xd1, 1, 2}, bplistString("k"), []byte{0x70}), "unsupported binary property list object 0x70"},
+	} {
+		if _, err := decodePlist(tt.data); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
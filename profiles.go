@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// profilesKey is the key of the section holding the named profiles of a configuration document.
+const profilesKey = "profiles"
+
+// SelectProfile deep-merges the named profile from the "profiles" section of the configuration
+// over the remaining keys and discards the section. Selecting the empty name leaves only the
+// keys outside the section. The section is captured on the first selection, so profiles can be
+// re-selected later: the values of the previously selected profile are reset to the values they
+// had before it was applied and the new profile is merged over the result. Documents read or
+// reloaded later have their profiles sections captured as well and the selected profile applied
+// to them; Load selects a profile with WithProfile. The change is applied atomically and
+// notified to change callbacks.
+func (c *Configuration) SelectProfile(name string) error {
+	c.mu.Lock()
+	if c.profiles == nil {
+		base, profiles, err := splitProfiles(c.fileKeyvals())
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		c.profileBase, c.profiles = base, profiles
+	}
+	keyvals := unapplyProfile(c.fileKeyvals(), c.profileBase, c.profiles[c.profile])
+	keyvals, err := applyProfile(keyvals, c.profiles, name)
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.profile = name
+	old := c.keyvals
+	c.setFileKeyvals(keyvals)
+	new := c.keyvals
+	c.record()
+	c.mu.Unlock()
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+	return nil
+}
+
+// Profile returns the name of the selected profile.
+func (c *Configuration) Profile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.profile
+}
+
+// withProfile applies the selected profile, if any, to a newly loaded document and captures its
+// profiles section for later selections. The caller must hold c.mu.
+func (c *Configuration) withProfile(keyvals map[string]any) (map[string]any, error) {
+	if c.profiles == nil {
+		return keyvals, nil
+	}
+	base, profiles, err := splitProfiles(keyvals)
+	if err != nil {
+		return nil, err
+	}
+	keyvals, err = applyProfile(deepCopyMap(base), profiles, c.profile)
+	if err != nil {
+		return nil, err
+	}
+	c.profileBase, c.profiles = base, profiles
+	return keyvals, nil
+}
+
+// mergeProfiled returns the files layer with the top level keys of keyvals merged into it and
+// the profile name applied, and captures the profiles section of the result. The values of the
+// selected profile are reset before merging, so profiles loaded earlier are replaced like any
+// other key. The caller must hold c.mu.
+func (c *Configuration) mergeProfiled(keyvals map[string]any, name string) (map[string]any, error) {
+	doc := deepCopyMap(c.fileKeyvals())
+	if c.profiles != nil {
+		doc = unapplyProfile(c.fileKeyvals(), c.profileBase, c.profiles[c.profile])
+		section := make(map[string]any, len(c.profiles))
+		for name, profile := range c.profiles {
+			section[name] = profile
+		}
+		doc[profilesKey] = section
+	}
+	for key, val := range keyvals {
+		doc[key] = val
+	}
+	base, profiles, err := splitProfiles(doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = applyProfile(deepCopyMap(base), profiles, name); err != nil {
+		return nil, err
+	}
+	c.profile, c.profileBase, c.profiles = name, base, profiles
+	return doc, nil
+}
+
+// splitProfiles returns deep copies of keyvals without the profiles section and of the profiles
+// section.
+func splitProfiles(keyvals map[string]any) (map[string]any, map[string]map[string]any, error) {
+	base := make(map[string]any, len(keyvals))
+	for key, val := range keyvals {
+		if key != profilesKey {
+			base[key] = deepCopy(resolve(val))
+		}
+	}
+	profiles := make(map[string]map[string]any)
+	section, ok := resolve(keyvals[profilesKey]).(map[string]any)
+	if !ok && keyvals[profilesKey] != nil {
+		return nil, nil, fmt.Errorf("config: %q must be an object", profilesKey)
+	}
+	for name, val := range section {
+		profile, ok := val.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("config: profile %q must be an object", name)
+		}
+		profiles[name] = deepCopyMap(profile)
+	}
+	return base, profiles, nil
+}
+
+// applyProfile returns keyvals with the named profile deep-merged over it.
+func applyProfile(keyvals map[string]any, profiles map[string]map[string]any, name string) (map[string]any, error) {
+	if name == "" {
+		return keyvals, nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("config: unknown profile %q, available profiles: %s", name, strings.Join(sortedKeys(profiles), ", "))
+	}
+	deepMerge(keyvals, deepCopyMap(profile))
+	return keyvals, nil
+}
+
+// unapplyProfile returns a deep copy of keyvals without the profiles section in which every
+// value set by profile is reset to its value in base, or removed if base does not have it.
+func unapplyProfile(keyvals, base, profile map[string]any) map[string]any {
+	res := make(map[string]any, len(keyvals))
+	for key, val := range keyvals {
+		if key != profilesKey {
+			res[key] = deepCopy(resolve(val))
+		}
+	}
+	for _, path := range leafPaths(profile, nil) {
+		if val, ok := getPath(base, path); ok {
+			setPath(res, path, deepCopy(val))
+		} else {
+			deletePath(res, path)
+		}
+	}
+	return res
+}
+
+// deepCopy returns a copy of val that shares no maps or slices with it.
+func deepCopy(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		return deepCopyMap(v)
+	case []any:
+		res := make([]any, len(v))
+		for i, elem := range v {
+			res[i] = deepCopy(elem)
+		}
+		return res
+	default:
+		return val
+	}
+}
+
+// deepCopyMap returns a copy of m that shares no maps or slices with it.
+func deepCopyMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	res := make(map[string]any, len(m))
+	for key, val := range m {
+		res[key] = deepCopy(resolve(val))
+	}
+	return res
+}
+
+// cloneProfiles returns a deep copy of the profiles section profiles, nil if it is nil.
+func cloneProfiles(profiles map[string]map[string]any) map[string]map[string]any {
+	if profiles == nil {
+		return nil
+	}
+	res := make(map[string]map[string]any, len(profiles))
+	for name, p := range profiles {
+		res[name] = deepCopyMap(p)
+	}
+	return res
+}
+
+// deepMerge merges src into dst, descending into objects present in both and replacing all other
+// values.
+func deepMerge(dst, src map[string]any) {
+	for key, val := range src {
+		val = resolve(val)
+		if sub, ok := val.(map[string]any); ok {
+			if dsub, ok := resolve(dst[key]).(map[string]any); ok {
+				deepMerge(dsub, sub)
+				dst[key] = dsub
+				continue
+			}
+		}
+		dst[key] = val
+	}
+}
+
+// leafPaths returns the paths of all values in m that are not non-empty objects, each path
+// prefixed by prefix.
+func leafPaths(m map[string]any, prefix []string) [][]string {
+	var paths [][]string
+	for key, val := range m {
+		path := append(append([]string(nil), prefix...), key)
+		if sub, ok := val.(map[string]any); ok && len(sub) > 0 {
+			paths = append(paths, leafPaths(sub, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// getPath returns the value at path in nested maps.
+func getPath(m map[string]any, path []string) (any, bool) {
+	for _, key := range path[:len(path)-1] {
+		sub, ok := resolve(m[key]).(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		m = sub
+	}
+	val, ok := m[path[len(path)-1]]
+	return resolve(val), ok
+}
+
+// setPath sets the value at path in nested maps, creating and replacing intermediate values as
+// needed.
+func setPath(m map[string]any, path []string, val any) {
+	for _, key := range path[:len(path)-1] {
+		sub, ok := m[key].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			m[key] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = val
+}
+
+// deletePath removes the value at path in nested maps.
+func deletePath(m map[string]any, path []string) {
+	for _, key := range path[:len(path)-1] {
+		sub, ok := m[key].(map[string]any)
+		if !ok {
+			return
+		}
+		m = sub
+	}
+	delete(m, path[len(path)-1])
+}
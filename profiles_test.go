@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const profilesDoc = `{
+	"db": {"host": "localhost", "port": 5432},
+	"debug": true,
+	"profiles": {
+		"prod": {"db": {"host": "db.internal"}, "debug": false},
+		"test": {"db": {"port": 5433}}
+	}
+}`
+
+func TestSelectProfile(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(profilesDoc)); err != nil {
+		t.Fatal(err)
+	}
+	err := c.SelectProfile("staging")
+	if err == nil || !strings.Contains(err.Error(), "prod, test") {
+		t.Fatalf("got %v, want an unknown profile error listing the profiles", err)
+	}
+	if _, ok := c.Get("profiles"); !ok {
+		t.Fatal("a failed selection changed the configuration")
+	}
+
+	if err := c.SelectProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.host"); got != "db.internal" {
+		t.Errorf("prod db.host: got %q", got)
+	}
+	if got := c.GetInt("db.port"); got != 5432 {
+		t.Errorf("prod db.port: got %d, want the value outside the profile", got)
+	}
+	if _, ok := c.Get("profiles"); ok {
+		t.Error("the profiles section was kept")
+	}
+
+	// selecting again resets the values of the previous profile
+	if err := c.SelectProfile("test"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprintf("%s %d %t", c.GetStr("db.host"), c.GetInt("db.port"), c.GetBool("debug")), "localhost 5433 true"; got != want {
+		t.Errorf("test: got %q, want %q", got, want)
+	}
+	if err := c.SelectProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprintf("%s %d %t", c.GetStr("db.host"), c.GetInt("db.port"), c.GetBool("debug")), "localhost 5432 true"; got != want {
+		t.Errorf("empty profile: got %q, want %q", got, want)
+	}
+	if c.Profile() != "" {
+		t.Errorf("got profile %q, want none", c.Profile())
+	}
+}
+
+func TestSelectProfileRead(t *testing.T) {
+	dir := t.TempDir()
+	c := New()
+	if err := c.ReadFile(writeFile(t, dir, "app.json", profilesDoc)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SelectProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	// documents read after the selection have the profile applied and their section captured
+	more := `{"cache": {"size": 10}, "profiles": {"prod": {"cache": {"size": 100}}, "dev": {"cache": {"size": 1}}}}`
+	if err := c.ReadFile(writeFile(t, dir, "more.json", more)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("profiles"); ok {
+		t.Error("the profiles section of the read document was kept")
+	}
+	if got := c.GetInt("cache.size"); got != 100 {
+		t.Errorf("cache.size: got %d, want the value of the prod profile", got)
+	}
+	if got := c.GetStr("db.host"); got != "localhost" {
+		t.Errorf("db.host: got %q, want the value outside the profiles replacing them", got)
+	}
+	if err := c.SelectProfile("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("cache.size"); got != 1 {
+		t.Errorf("dev cache.size: got %d, want 1", got)
+	}
+
+	// a document replacing the section must still have the selected profile
+	if err := c.ReadFile(writeFile(t, dir, "other.json", `{"profiles": {"prod": {}}}`)); err == nil {
+		t.Error("read a document without the selected profile")
+	}
+	if got := c.GetInt("cache.size"); got != 1 {
+		t.Errorf("a failed read changed cache.size to %d", got)
+	}
+}
+
+func TestLoadWithProfile(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", profilesDoc)
+	c := New()
+	if err := c.Load(fname, WithProfile("staging")); err == nil {
+		t.Fatal("loaded an unknown profile")
+	}
+	if len(c.AllSettings()) != 0 {
+		t.Fatalf("a failed load changed the configuration: %v", c.AllSettings())
+	}
+	if err := c.Load(fname, WithProfile("prod"), WithStrict(), WithDefaults(map[string]any{"db": map[string]any{}, "debug": true})); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.host"); got != "db.internal" || c.Profile() != "prod" {
+		t.Errorf("got db.host %q with profile %q, want the prod profile", got, c.Profile())
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.host"); got != "db.internal" {
+		t.Errorf("after reload: got db.host %q", got)
+	}
+}
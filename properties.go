@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterFormat(".properties", decodeProperties, nil)
+}
+
+// decodeProperties decodes a Java properties document into a key-value map. Dot separated
+// property names are mapped to nested objects, so "server.port=80" sets "port" inside the
+// object "server"; a property whose name is also the prefix of other properties is rejected.
+// Keys and values are separated by "=", ":" or whitespace, lines starting with "#" or "!" are
+// comments, lines ending in an odd number of backslashes continue on the next line, and the
+// escape sequences \t, \n, \r, \f and \uXXXX are interpreted. All values are strings.
+// Properties documents can only be read.
+func decodeProperties(data []byte) (map[string]any, error) {
+	keyvals := make(map[string]any)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for n := 0; n < len(lines); n++ {
+		start := n + 1
+		line := strings.TrimLeft(lines[n], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continues(line) && n+1 < len(lines) {
+			n++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[n], " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, val := splitProperty(line)
+		k, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("config: line %d: %w", start, err)
+		}
+		v, err := unescapeProperty(val)
+		if err != nil {
+			return nil, fmt.Errorf("config: line %d: %w", start, err)
+		}
+		if err := setProperty(keyvals, strings.Split(k, "."), v); err != nil {
+			return nil, fmt.Errorf("config: line %d: %w", start, err)
+		}
+	}
+	return keyvals, nil
+}
+
+// continues reports whether line ends in an odd number of backslashes.
+func continues(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, `\`))
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into its unescaped key and value.
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	key, rest := line[:end], strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty interprets the escape sequences in s.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// setProperty sets the value at path in nested maps, failing if path conflicts with a property
+// set before.
+func setProperty(m map[string]any, path []string, val string) error {
+	name := strings.Join(path, ".")
+	for i, key := range path[:len(path)-1] {
+		switch sub := m[key].(type) {
+		case map[string]any:
+			m = sub
+		case nil:
+			next := make(map[string]any)
+			m[key] = next
+			m = next
+		default:
+			return fmt.Errorf("property %q conflicts with property %q", name, strings.Join(path[:i+1], "."))
+		}
+	}
+	last := path[len(path)-1]
+	if _, ok := m[last].(map[string]any); ok {
+		return fmt.Errorf("property %q conflicts with nested properties", name)
+	}
+	m[last] = val
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeProperties(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      map[string]any
+	}{
+		{"separators", "a=1\nb: 2\nc 3\nd = 4\ne\n", map[string]any{"a": "1", "b": "2", "c": "3", "d": "4", "e": ""}},
+		{"nested names", "server.host=localhost\nserver.port=80\n", map[string]any{
+			"server": map[string]any{"host": "localhost", "port": "80"},
+		}},
+		{"comments and blank lines", "# comment\n! comment\n\n  \t key = value \n", map[string]any{"key": "value "}},
+		{"escapes", `a=tab\there\nnl\u00e9\=\:` + "\n" + `key\ with\=sep=x` + "\n", map[string]any{
+			"a": "tab\there\nnl\u00e9=:", "key with=sep": "x",
+		}},
+		{"continuation lines", "list = a, \\\n    b, \\\n    c\nnext = 1\n", map[string]any{"list": "a, b, c", "next": "1"}},
+		{"escaped backslash at line end", "path = C:\\\\\nnext = 1\n", map[string]any{"path": `C:\`, "next": "1"}},
+		{"continuation at end of input", "a = x\\", map[string]any{"a": "x"}},
+		{"duplicate keys", "a=1\na=2\n", map[string]any{"a": "2"}},
+		{"windows line endings", "a=1\r\nb=2\r\n", map[string]any{"a": "1", "b": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeProperties([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePropertiesErrors(t *testing.T) {
+	for _, tt := range []struct{ doc, err string }{
+		{"a=1\na.b=2\n", `line 2: property "a.b" conflicts with property "a"`},
+		{"a.b=1\na=2\n", `line 2: property "a" conflicts with nested properties`},
+		{"a=\\u12\n", "line 1: invalid unicode escape"},
+		{"x=1\na=\\\n  \\uzzzz\n", "line 2: invalid unicode escape"},
+	} {
+		if _, err := decodeProperties([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Provider is a Source that can also push changes of its document, such as a key-value store
+// with watch support.
+type Provider interface {
+	Source
+	// Watch calls fn with the complete current document whenever it changes, until ctx is done
+	// or watching fails. Calls of fn must not overlap. Watch returns nil when ctx is done.
+	Watch(ctx context.Context, fn func(map[string]any)) error
+}
+
+// watchRetry is the delay before watching a provider again after its Watch method failed for the
+// first time; it doubles with every consecutive failure.
+const watchRetry = time.Second
+
+// StartWatch loads the document provided by p into the configuration and starts a goroutine that
+// keeps the configuration updated with the documents p reports until ctx is done. Updates are
+// applied like refreshes started with StartRefresh: keys that are no longer provided are
+// removed, documents with validation errors are rejected and changes are notified like reloads.
+// When watching fails it is restarted with exponential backoff, and the failures are recorded in
+// the Metrics of the configuration. StartWatch only returns an error if the initial load fails.
+func (c *Configuration) StartWatch(ctx context.Context, p Provider) error {
+	keyvals, err := p.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("config: loading from %s: %w", p.Name(), err)
+	}
+	if err := c.applyRefresh(nil, keyvals); err != nil {
+		return fmt.Errorf("config: loading from %s: %w", p.Name(), err)
+	}
+	go c.watchProvider(ctx, p, keyvals)
+	return nil
+}
+
+// watchProvider implements StartWatch once the initial document prev was applied.
+func (c *Configuration) watchProvider(ctx context.Context, p Provider, prev map[string]any) {
+	failures := 0
+	for {
+		err := p.Watch(ctx, func(keyvals map[string]any) {
+			if err := c.applyRefresh(prev, keyvals); err != nil {
+				failures++
+				c.recordRefresh(fmt.Errorf("config: watching %s: %w", p.Name(), err), failures)
+				return
+			}
+			prev, failures = keyvals, 0
+			c.recordRefresh(nil, 0)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("watch ended")
+		}
+		failures++
+		c.recordRefresh(fmt.Errorf("config: watching %s: %w", p.Name(), err), failures)
+
+		t := time.NewTimer(backoff(watchRetry, failures))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
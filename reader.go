@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// ReadFrom is like Configuration.ReadFrom for the global configuration.
+func ReadFrom(r io.Reader, format Format) error {
+	return config.ReadFrom(r, format)
+}
+
+// ReadFrom reads a configuration document in format from r and updates c. Documents larger than
+// MaxFileSize are rejected. The format must be registered, either by the package or with
+// RegisterFormat, and is given by its extension, such as YAML or Format(".yaml").
+func (c *Configuration) ReadFrom(r io.Reader, format Format) error {
+	f, ok := lookupFormat(string(format))
+	if !ok {
+		return fmt.Errorf("config: unknown format %q", format)
+	}
+	data, err := readLimited(r)
+	if err != nil {
+		return err
+	}
+	keyvals, err := c.decodeFormat(f, data)
+	if err != nil {
+		return err
+	}
+	return c.merge(keyvals, nil, hashOf(data))
+}
+
+// LoadBytes is like Configuration.LoadBytes for the global configuration.
+func LoadBytes(data []byte) error {
+	return config.LoadBytes(data)
+}
+
+// LoadBytes reads the configuration document data and updates c. As data has no file name, its
+// format is detected from the content like Load does for files with an unknown extension.
+func (c *Configuration) LoadBytes(data []byte) error {
+	ext := sniffFormat(data)
+	if ext == "" {
+		return errors.New("config: cannot detect the format of the document")
+	}
+	return c.ReadFrom(bytes.NewReader(data), Format(ext))
+}
+
+// LoadString is like Configuration.LoadString for the global configuration.
+func LoadString(s string) error {
+	return config.LoadString(s)
+}
+
+// LoadString is like LoadBytes for a document held in a string.
+func (c *Configuration) LoadString(s string) error {
+	return c.LoadBytes([]byte(s))
+}
+
+// ReadFS is like Configuration.ReadFS for the global configuration.
+func ReadFS(fsys fs.FS, name string) error {
+	return config.ReadFS(fsys, name)
+}
+
+// ReadFS reads the configuration file name from fsys, such as an embed.FS holding default
+// configuration baked into the binary, and updates c like ReadFile. Files read later are layered on
+// top, and Reload re-reads the file from fsys in its place among the other files.
+func (c *Configuration) ReadFS(fsys fs.FS, name string) error {
+	files := []fileSource{{name: name, fsys: fsys}}
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return err
+	}
+	return c.merge(keyvals, files, hash)
+}
+
+// ReadStdin is like Configuration.ReadStdin for the global configuration.
+func ReadStdin(format Format) error {
+	return config.ReadStdin(format)
+}
+
+// ReadStdin reads a configuration document in format from the standard input and updates c, so
+// documents can be piped from tools such as sops or kubectl without being written to a file. An
+// empty format detects the format from the content like LoadBytes.
+func (c *Configuration) ReadStdin(format Format) error {
+	if format != "" {
+		return c.ReadFrom(os.Stdin, format)
+	}
+	data, err := readLimited(os.Stdin)
+	if err != nil {
+		return err
+	}
+	return c.LoadBytes(data)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// defaultRefreshInterval is the interval StartRefresh uses when given an interval of 0 or less.
+const defaultRefreshInterval = time.Minute
+
+// maxBackoff is the maximum multiple of the refresh interval StartRefresh waits after
+// consecutive failures.
+const maxBackoff = 32
+
+// StartRefresh starts a goroutine that loads the configuration document provided by src right
+// away and then every interval until ctx is done. Keys that a refresh no longer receives from
+// src are removed from the configuration, all others are set to the received values. Documents
+// with validation errors, see ValidateBytes, are rejected. Changes are applied atomically and
+// notified like reloads. After consecutive failures the refreshes back off exponentially with
+// jitter up to 32 intervals, while the configuration keeps the last good data. A refresh still
+// running when the next one is due, because src is slow, causes that refresh to be skipped and
+// logged. The outcome of the refreshes is recorded in the Metrics of the configuration. An
+// interval of 0 or less refreshes every minute.
+func (c *Configuration) StartRefresh(ctx context.Context, src Source, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	go c.refresh(ctx, src, interval)
+}
+
+// refreshResult is the outcome of loading a source.
+type refreshResult struct {
+	keyvals map[string]any
+	err     error
+}
+
+// refresh implements StartRefresh.
+func (c *Configuration) refresh(ctx context.Context, src Source, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	results := make(chan refreshResult, 1)
+	load := func() {
+		go func() {
+			keyvals, err := src.Load(ctx)
+			results <- refreshResult{keyvals, err}
+		}()
+	}
+
+	var prev map[string]any
+	var next time.Time // earliest time of the next refresh while backing off
+	failures := 0
+	busy := true
+	load()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			switch {
+			case busy:
+				log.Printf("config: refresh of %s still running, skipping refresh", src.Name())
+			case now.Before(next):
+			default:
+				busy = true
+				load()
+			}
+		case r := <-results:
+			busy = false
+			if ctx.Err() != nil {
+				return
+			}
+			err := r.err
+			if err == nil {
+				err = c.applyRefresh(prev, r.keyvals)
+			}
+			if err != nil {
+				failures++
+				next = time.Now().Add(backoff(interval, failures))
+				c.recordRefresh(fmt.Errorf("config: refreshing from %s: %w", src.Name(), err), failures)
+				continue
+			}
+			prev, failures, next = r.keyvals, 0, time.Time{}
+			c.recordRefresh(nil, 0)
+		}
+	}
+}
+
+// applyRefresh validates keyvals and replaces the keys of the previous refresh prev with it.
+func (c *Configuration) applyRefresh(prev, keyvals map[string]any) error {
+	var errs []Finding
+	for _, f := range c.findings(keyvals) {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Findings: errs}
+	}
+
+	c.mu.Lock()
+	old := c.keyvals
+	files := c.fileKeyvals()
+	res := make(map[string]any, len(files)+len(keyvals))
+	for key, val := range files {
+		res[key] = val
+	}
+	for key := range prev {
+		if _, ok := keyvals[key]; !ok {
+			delete(res, key)
+		}
+	}
+	for key, val := range keyvals {
+		res[key] = val
+	}
+	c.setFileKeyvals(res)
+	new := c.keyvals
+	c.record()
+	c.mu.Unlock()
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+	return nil
+}
+
+// backoff returns the delay before the next refresh after the given number of consecutive
+// failures: the interval doubled for every failure after the first, up to maxBackoff intervals,
+// with up to half of it replaced by random jitter.
+func backoff(interval time.Duration, failures int) time.Duration {
+	d := interval
+	for i := 1; i < failures && d < maxBackoff*interval; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff*interval)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// recordRefresh records the outcome of a refresh in the metrics.
+func (c *Configuration) recordRefresh(err error, failures int) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	c.metrics.ConsecutiveFailures = failures
+	if err != nil {
+		c.metrics.LastRefreshError = err
+		return
+	}
+	c.metrics.LastRefreshSuccess = time.Now()
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource is a Source returning the number of times it was loaded.
+type countingSource struct {
+	loads atomic.Int64
+}
+
+func (s *countingSource) Name() string { return "counting" }
+
+func (s *countingSource) Load(context.Context) (map[string]any, error) {
+	return map[string]any{"loads": int(s.loads.Add(1))}, nil
+}
+
+func TestStartRefreshInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := New()
+		src := new(countingSource)
+		c.StartRefresh(ctx, src, interval)
+		eventually(t, "the initial refresh", func() bool { return c.GetInt("loads") == 1 })
+		time.Sleep(50 * time.Millisecond)
+		if got := src.loads.Load(); got != 1 {
+			t.Errorf("interval %v: got %d refreshes, want the default interval", interval, got)
+		}
+		cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New()
+	c.StartRefresh(ctx, new(countingSource), 10*time.Millisecond)
+	eventually(t, "repeated refreshes", func() bool { return c.GetInt("loads") >= 3 })
+}
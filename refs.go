@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// refOpen starts an inter-key reference such as ${ref:host}.
+const refOpen = "${ref:"
+
+// ResolveRefs replaces ${ref:key} references inside string values, including strings nested in
+// objects and arrays, with the value stored under the referenced key. Keys are looked up like Get
+// does, so ${ref:db.host} refers to the value "host" in the object "db" and defaults are used for
+// keys that are not set. Referenced floats are written in their shortest form, such as "5432"
+// and "0.5", other non-string values are converted with ConvertTo[string], references inside
+// referenced values are resolved recursively and "$${ref:" yields a literal
+// "${ref:". References are replaced in place, so ResolveRefs is meant to be called once after
+// loading: calling it again also expands the references produced by escapes. Reference cycles
+// and references to missing keys, objects or arrays are reported as errors, in which case the
+// configuration is left unchanged. ResolveRefs fails with ErrFrozen if c is frozen.
+func (c *Configuration) ResolveRefs() error {
+	c.mu.Lock()
+	if err := c.checkFrozen(""); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	r := refResolver{
+		keyvals:  c.keyvals,
+		defaults: c.defaults,
+		delim:    c.delimiter(),
+		resolved: make(map[string]string),
+	}
+	new := make(map[string]any)
+	for _, key := range sortedKeys(c.keyvals) {
+		val, changed, err := r.walk(key, c.keyvals[key])
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if changed {
+			new[key] = val
+		}
+	}
+	old := make(map[string]any, len(new))
+	for key, val := range new {
+		old[key] = c.keyvals[key]
+		c.keyvals[key] = val
+		if c.layers != nil {
+			c.layers.replaceTop(key, val)
+		}
+	}
+	if len(new) > 0 {
+		c.record()
+	}
+	c.mu.Unlock()
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, false))
+	}
+	return nil
+}
+
+// refResolver resolves references between the values of a key-value map.
+type refResolver struct {
+	keyvals  map[string]any
+	defaults map[string]any
+	delim    string
+	resolved map[string]string // expanded strings by key path
+	stack    []string
+}
+
+// walk returns val, stored under the key path key, with the references in its strings expanded
+// and whether that changed it. Objects and arrays are copied if a value inside them changes.
+func (r *refResolver) walk(key string, val any) (any, bool, error) {
+	switch v := resolve(val).(type) {
+	case string:
+		s, err := r.expandAt(key, v)
+		return s, s != v, err
+	case map[string]any:
+		var res map[string]any
+		for _, k := range sortedKeys(v) {
+			elem, changed, err := r.walk(key+r.delim+k, v[k])
+			if err != nil {
+				return nil, false, err
+			}
+			if changed {
+				if res == nil {
+					res = maps.Clone(v)
+				}
+				res[k] = elem
+			}
+		}
+		if res == nil {
+			return val, false, nil
+		}
+		return res, true, nil
+	case []any:
+		var res []any
+		for i := range v {
+			elem, changed, err := r.walk(key+r.delim+strconv.Itoa(i), v[i])
+			if err != nil {
+				return nil, false, err
+			}
+			if changed {
+				if res == nil {
+					res = slices.Clone(v)
+				}
+				res[i] = elem
+			}
+		}
+		if res == nil {
+			return val, false, nil
+		}
+		return res, true, nil
+	default:
+		return val, false, nil
+	}
+}
+
+// expandAt returns the string s stored under the key path key with all references expanded.
+func (r *refResolver) expandAt(key, s string) (string, error) {
+	if val, ok := r.resolved[key]; ok {
+		return val, nil
+	}
+	for i, k := range r.stack {
+		if k == key {
+			cycle := append(append([]string{}, r.stack[i:]...), key)
+			return "", fmt.Errorf("config: reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	r.stack = append(r.stack, key)
+	val, err := r.expand(key, s)
+	r.stack = r.stack[:len(r.stack)-1]
+	if err != nil {
+		return "", err
+	}
+	r.resolved[key] = val
+	return val, nil
+}
+
+// expand replaces the references in s, the value stored under key.
+func (r *refResolver) expand(key, s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, refOpen)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString(refOpen)
+			s = s[i+len(refOpen):]
+			continue
+		}
+		b.WriteString(s[:i])
+		s = s[i+len(refOpen):]
+
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", fmt.Errorf("config: key %q contains an unterminated reference", key)
+		}
+		ref := indexPath(s[:end], r.delim)
+		s = s[end+1:]
+		val, ok := findPath(r.keyvals, ref, r.delim)
+		if !ok {
+			val, ok = findPath(r.defaults, ref, r.delim)
+		}
+		if !ok {
+			return "", fmt.Errorf("config: key %q references missing key %q", key, ref)
+		}
+		switch v := resolve(val).(type) {
+		case string:
+			expanded, err := r.expandAt(ref, v)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+		case map[string]any, []any:
+			return "", fmt.Errorf("config: key %q references %q, which is not a single value", key, ref)
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			b.WriteString(ConvertTo[string](v))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{
+		"db": {"host": "db.local", "port": 5432, "ratio": 0.5},
+		"url": "postgres://${ref:db.host}:${ref:db.port}/app",
+		"mirrors": [{"url": "${ref:url}?mirror=1"}, "${ref:mirrors[0].url}&n=${ref:db.ratio}"],
+		"escaped": "$${ref:db.host}",
+		"user": "${ref:default_user}"
+	}`)
+	c := New()
+	c.SetDefault("default_user", "admin")
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ResolveRefs(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"url":           "postgres://db.local:5432/app",
+		"mirrors.0.url": "postgres://db.local:5432/app?mirror=1",
+		"mirrors.1":     "postgres://db.local:5432/app?mirror=1&n=0.5",
+		"escaped":       "${ref:db.host}",
+		"user":          "admin",
+	}
+	for key, val := range want {
+		if got := c.GetStr(key); got != val {
+			t.Errorf("%s: got %q, want %q", key, got, val)
+		}
+	}
+}
+
+func TestResolveRefsDelimiter(t *testing.T) {
+	c := New()
+	c.SetKeyDelimiter("/")
+	c.Set("db", map[string]any{"host": "db.local"})
+	c.Set("url", "http://${ref:db/host}")
+	if err := c.ResolveRefs(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("url"); got != "http://db.local" {
+		t.Fatalf("got %q, want http://db.local", got)
+	}
+}
+
+func TestResolveRefsErrors(t *testing.T) {
+	tests := []struct {
+		keyvals map[string]any
+		err     string
+	}{
+		{map[string]any{"a": "${ref:b.c}", "b": map[string]any{"c": "${ref:a}"}}, "reference cycle: a -> b.c -> a"},
+		{map[string]any{"a": []any{"${ref:missing}"}}, `references missing key "missing"`},
+		{map[string]any{"a": "${ref:b}", "b": map[string]any{}}, "not a single value"},
+		{map[string]any{"a": "${ref:b"}, "unterminated reference"},
+	}
+	for _, tt := range tests {
+		c := New()
+		for key, val := range tt.keyvals {
+			c.Set(key, val)
+		}
+		err := c.ResolveRefs()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: got error %v, want %q", tt.keyvals, err, tt.err)
+		}
+	}
+
+	c := New()
+	c.Set("a", "${ref:b}")
+	c.Set("b", "x")
+	c.Freeze()
+	if err := c.ResolveRefs(); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("got error %v, want ErrFrozen", err)
+	}
+	if got := c.GetStr("a"); got != "${ref:b}" {
+		t.Fatalf("frozen configuration changed to %q", got)
+	}
+}
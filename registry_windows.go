@@ -0,0 +1,175 @@
+//go:build windows
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// RegistrySource is a Provider reading configuration values from a subtree of the Windows
+// registry, such as HKEY_LOCAL_MACHINE\SOFTWARE\MyCompany\MyAgent. The values of a key set the
+// keys named like them, and subkeys become nested objects. String values are used as strings,
+// with environment variables expanded in REG_EXPAND_SZ values, REG_MULTI_SZ values as arrays of
+// strings and REG_DWORD and REG_QWORD values as integers. Unnamed default values and values of
+// other types are skipped.
+type RegistrySource struct {
+	Root registry.Key // predefined root key, such as registry.LOCAL_MACHINE
+	Path string       // path of the subtree below Root
+}
+
+// NewRegistrySource returns a RegistrySource reading the subtree at path below root.
+func NewRegistrySource(root registry.Key, path string) *RegistrySource {
+	return &RegistrySource{Root: root, Path: path}
+}
+
+// Name returns the path of the subtree.
+func (s *RegistrySource) Name() string {
+	return "registry:" + s.Path
+}
+
+// Load reads the values of the subtree.
+func (s *RegistrySource) Load(ctx context.Context) (map[string]any, error) {
+	return readRegistryKey(ctx, s.Root, s.Path)
+}
+
+// Watch reports the document whenever a value or key of the subtree was added, removed or
+// changed.
+func (s *RegistrySource) Watch(ctx context.Context, fn func(map[string]any)) error {
+	k, err := registry.OpenKey(s.Root, s.Path, registry.NOTIFY)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	ev, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(ev)
+
+	var prev map[string]any
+	for {
+		// notifications trigger once, so the next one is requested before reading the
+		// subtree to not miss changes made while reading
+		err := windows.RegNotifyChangeKeyValue(windows.Handle(k), true,
+			windows.REG_NOTIFY_CHANGE_NAME|windows.REG_NOTIFY_CHANGE_LAST_SET, ev, true)
+		if err != nil {
+			return err
+		}
+		doc, err := s.Load(ctx)
+		if err != nil {
+			return err
+		}
+		if prev == nil || !reflect.DeepEqual(doc, prev) {
+			prev = doc
+			fn(deepCopyMap(doc))
+		}
+		if err := waitEvent(ctx, ev); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// waitEvent waits until ev is signaled or ctx is done, in which case it returns the error of ctx.
+func waitEvent(ctx context.Context, ev windows.Handle) error {
+	for {
+		// WaitForSingleObject cannot be interrupted, so ctx is checked between short waits
+		r, err := windows.WaitForSingleObject(ev, 250)
+		switch {
+		case err != nil:
+			return err
+		case r == windows.WAIT_OBJECT_0:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+	}
+}
+
+// readRegistryKey reads the values and subkeys of the key at path below parent.
+func readRegistryKey(ctx context.Context, parent registry.Key, path string) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	k, err := registry.OpenKey(parent, path, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, fmt.Errorf("config: opening registry key %s: %w", path, err)
+	}
+	defer k.Close()
+
+	doc := make(map[string]any)
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		val, err := registryValue(k, name)
+		if errors.Is(err, registry.ErrUnexpectedType) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config: reading registry value %s\\%s: %w", path, name, err)
+		}
+		doc[name] = val
+	}
+
+	subkeys, err := k.ReadSubKeyNames(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range subkeys {
+		if _, ok := doc[name]; ok {
+			return nil, fmt.Errorf("config: registry key %s\\%s conflicts with a value", path, name)
+		}
+		if doc[name], err = readRegistryKey(ctx, k, name); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// registryValue returns the value name of k, failing with registry.ErrUnexpectedType for types
+// that are not supported.
+func registryValue(k registry.Key, name string) (any, error) {
+	_, typ, err := k.GetValue(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case registry.SZ:
+		s, _, err := k.GetStringValue(name)
+		return s, err
+	case registry.EXPAND_SZ:
+		s, _, err := k.GetStringValue(name)
+		if err != nil {
+			return nil, err
+		}
+		return registry.ExpandString(s)
+	case registry.MULTI_SZ:
+		strs, _, err := k.GetStringsValue(name)
+		if err != nil {
+			return nil, err
+		}
+		vals := make([]any, len(strs))
+		for i, s := range strs {
+			vals[i] = s
+		}
+		return vals, nil
+	case registry.DWORD, registry.QWORD:
+		n, _, err := k.GetIntegerValue(name)
+		return int64(n), err
+	default:
+		return nil, registry.ErrUnexpectedType
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Metrics describes the reloads of a configuration, both by Reload and by a Watcher, and the
+// periodic refreshes of its sources.
+type Metrics struct {
+	ReloadAttempts   uint64
+	ReloadSuccesses  uint64
+	ReloadFailures   uint64
+	LastSuccess      time.Time     // time of the last successful reload
+	LastFailure      time.Time     // time of the last failed reload
+	LastError        error         // error of the last failed reload
+	LastLoadDuration time.Duration // duration of the last reload, successful or not
+
+	LastRefreshSuccess  time.Time // time of the last successful refresh started by StartRefresh
+	LastRefreshError    error     // error of the last failed refresh
+	ConsecutiveFailures int       // refreshes failed since the last successful one
+}
+
+// Reload replaces the content of the configuration with the current content of the files it was
+// read from, merged in the order they were first read. Unlike ReadFile, which adds the keys of a
+// file to the configuration, Reload drops keys that are no longer present in the files. A failed
+// reload leaves the configuration unchanged.
+func (c *Configuration) Reload() error {
+	_, err := c.reloadFiles("")
+	return err
+}
+
+// Metrics returns the reload metrics of the configuration.
+func (c *Configuration) Metrics() Metrics {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	return c.metrics
+}
+
+// Collector returns the reload metrics of the configuration by name, with counters as totals,
+// times as Unix timestamps in seconds and durations in seconds, for exporting them to metrics
+// systems such as Prometheus or expvar. Timestamps of events that did not happen yet are 0.
+func (c *Configuration) Collector() map[string]float64 {
+	m := c.Metrics()
+	return map[string]float64{
+		"config_reload_attempts_total":                  float64(m.ReloadAttempts),
+		"config_reload_successes_total":                 float64(m.ReloadSuccesses),
+		"config_reload_failures_total":                  float64(m.ReloadFailures),
+		"config_last_reload_success_timestamp_seconds":  unixSeconds(m.LastSuccess),
+		"config_last_reload_failure_timestamp_seconds":  unixSeconds(m.LastFailure),
+		"config_last_load_duration_seconds":             m.LastLoadDuration.Seconds(),
+		"config_last_refresh_success_timestamp_seconds": unixSeconds(m.LastRefreshSuccess),
+		"config_refresh_consecutive_failures":           float64(m.ConsecutiveFailures),
+	}
+}
+
+// unixSeconds returns t as Unix time in seconds, or 0 for the zero time.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
+
+// reloadFiles reloads the configuration from its files like Reload and records the outcome in
+// the reload metrics. The configuration is left as is if the combined hash of the file contents
+// equals skipHash. It returns the combined hash.
+func (c *Configuration) reloadFiles(skipHash string) (string, error) {
+	start := time.Now()
+	hash, err := c.loadFiles(skipHash)
+	c.recordReload(start, err)
+	return hash, err
+}
+
+// loadFiles implements reloadFiles without recording metrics.
+func (c *Configuration) loadFiles(skipHash string) (string, error) {
+	c.mu.RLock()
+	files := append([]fileSource(nil), c.files...)
+	c.mu.RUnlock()
+	if len(files) == 0 {
+		return "", errors.New("config: configuration was not read from a file")
+	}
+
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return "", err
+	}
+	if hash == skipHash {
+		return hash, nil
+	}
+
+	c.mu.Lock()
+	keyvals, err = c.withProfile(keyvals)
+	if err == nil {
+		c.files = files
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	c.replace(keyvals, hash)
+	return hash, nil
+}
+
+// readFiles reads and merges files in order and returns the result together with the combined
+// hash of the contents of the files and the files they include, which is the hash of the content
+// of the only file if just one was read. The present flags of files are updated.
+func (c *Configuration) readFiles(files []fileSource) (map[string]any, string, error) {
+	keyvals, inputs, err := c.readInputs(files)
+	if err != nil {
+		return nil, "", err
+	}
+	return keyvals, inputsHash(inputs), nil
+}
+
+// readInputs implements readFiles and returns the inputs of the result in the order they were
+// read instead of their hash: the names of the files and the files they include together with the
+// hashes of their contents, as "name:hash".
+func (c *Configuration) readInputs(files []fileSource) (map[string]any, []string, error) {
+	keyvals := make(map[string]any)
+	var inputs []string
+	for i, f := range files {
+		data, err := f.read()
+		if err != nil {
+			if f.optional && errors.Is(err, fs.ErrNotExist) {
+				files[i].present = false
+				continue
+			}
+			return nil, nil, err
+		}
+		if err := f.verify(data); err != nil {
+			return nil, nil, err
+		}
+		files[i].present = true
+		inputs = append(inputs, f.name+":"+hashOf(data))
+		name := f.name
+		if f.format != "" {
+			name = f.format
+		}
+		doc, err := c.decode(name, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: %s: %w", f.name, err)
+		}
+		if doc, err = c.include(f, doc, nil, &inputs); err != nil {
+			return nil, nil, err
+		}
+		if f.flat {
+			doc = Flatten(doc, ".")
+		}
+		if f.deep {
+			deepMerge(keyvals, doc)
+			continue
+		}
+		for key, val := range doc {
+			keyvals[key] = val
+		}
+	}
+	return keyvals, inputs, nil
+}
+
+// inputsHash returns the combined hash of inputs as returned by readInputs, which is the hash of
+// the only input if there is just one.
+func inputsHash(inputs []string) string {
+	if len(inputs) == 1 {
+		return inputs[0][strings.LastIndexByte(inputs[0], ':')+1:]
+	}
+	return hashOf([]byte(strings.Join(inputs, "\n")))
+}
+
+// recordReload records a reload that started at start and failed with err, if not nil.
+func (c *Configuration) recordReload(start time.Time, err error) {
+	now := time.Now()
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	c.metrics.ReloadAttempts++
+	c.metrics.LastLoadDuration = now.Sub(start)
+	if err != nil {
+		c.metrics.ReloadFailures++
+		c.metrics.LastFailure = now
+		c.metrics.LastError = err
+		return
+	}
+	c.metrics.ReloadSuccesses++
+	c.metrics.LastSuccess = now
+}
+
+// fileSource is a file the configuration was read from.
+type fileSource struct {
+	name     string
+	optional bool   // the file may be missing
+	deep     bool   // deep-merged over the preceding files rather than replacing their top level keys
+	present  bool   // the file existed when it was last read
+	format   string // extension of the format the file is decoded in, if not given by its name
+	fsys     fs.FS  // file system holding the file, nil for the operating system's
+	flat     bool   // nested objects are flattened into dot separated keys
+
+	checksum string            // hex encoded SHA-256 the content must match, if not empty
+	pub      ed25519.PublicKey // key the content must be signed with, if not nil
+	sig      []byte            // signature of the content by pub
+}
+
+// read returns the content of the file.
+func (f fileSource) read() ([]byte, error) {
+	if f.fsys != nil {
+		return readFSFile(f.fsys, f.name)
+	}
+	return readFile(f.name)
+}
+
+// Sources returns the names of the files the configuration was read from, in load order. Optional
+// files, such as local override files, are only included if they existed when they were last read.
+func (c *Configuration) Sources() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var names []string
+	for _, f := range c.files {
+		if f.present {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// addFiles records files as files the configuration was read from. The caller must hold c.mu.
+func (c *Configuration) addFiles(files []fileSource) {
+next:
+	for _, f := range files {
+		for i := range c.files {
+			// files of other file systems may share names and cannot be compared
+			if c.files[i].name == f.name && c.files[i].fsys == nil && f.fsys == nil {
+				c.files[i] = f
+				continue next
+			}
+		}
+		c.files = append(c.files, f)
+	}
+}
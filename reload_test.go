@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReloadFailureMetrics(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"v": 1}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fname, []byte(`{"v": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Reload()
+	if err == nil {
+		t.Fatal("reloading invalid JSON succeeded")
+	}
+	if got := c.GetInt("v"); got != 1 {
+		t.Fatalf("failed reload changed v to %d", got)
+	}
+
+	m := c.Metrics()
+	if m.ReloadAttempts != 2 || m.ReloadSuccesses != 1 || m.ReloadFailures != 1 {
+		t.Fatalf("got %d attempts, %d successes and %d failures, want 2, 1 and 1",
+			m.ReloadAttempts, m.ReloadSuccesses, m.ReloadFailures)
+	}
+	if m.LastError != err {
+		t.Fatalf("got last error %v, want %v", m.LastError, err)
+	}
+	if m.LastFailure.IsZero() || m.LastFailure.Before(m.LastSuccess) {
+		t.Fatalf("last failure %v is not after last success %v", m.LastFailure, m.LastSuccess)
+	}
+	if got := c.Collector()["config_reload_failures_total"]; got != 1 {
+		t.Fatalf("collector reports %v failures, want 1", got)
+	}
+}
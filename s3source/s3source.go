@@ -0,0 +1,98 @@
+// Package s3source provides a configuration source downloading a configuration document from an
+// S3 object:
+//
+//	src := s3source.New(s3.NewFromConfig(awsCfg), "fleet-config", "myapp/config.yaml")
+//	config.Config().StartRefresh(ctx, src, time.Minute)
+//
+// The document is decoded in the format registered for the extension of the object key, JSON by
+// default. The source remembers the ETag of the last download and sends it in If-None-Match, so
+// periodic refreshes only transfer the object again after it changed. Setting VersionID pins the
+// source to one version of the object, which is then downloaded only once.
+package s3source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// API is the part of the S3 client used by Source, implemented by *s3.Client.
+type API interface {
+	GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Source is a config.Source downloading a configuration document from an S3 object.
+type Source struct {
+	Client    API
+	Bucket    string
+	Key       string
+	VersionID string // version of the object to read, empty for the latest
+
+	mu   sync.Mutex
+	etag string
+	data []byte // content of the last download
+}
+
+// New returns a Source downloading the latest version of the object key in bucket using client.
+func New(client API, bucket, key string) *Source {
+	return &Source{Client: client, Bucket: bucket, Key: key}
+}
+
+// Name identifies the source by its S3 URI.
+func (s *Source) Name() string {
+	name := "s3://" + s.Bucket + "/" + s.Key
+	if s.VersionID != "" {
+		name += "?versionId=" + s.VersionID
+	}
+	return name
+}
+
+// Load downloads the object, or decodes the last download again if the object is unchanged or
+// the pinned version was already downloaded.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data != nil && s.VersionID != "" {
+		return config.Decode(s.Key, s.data)
+	}
+
+	in := &s3.GetObjectInput{Bucket: aws.String(s.Bucket), Key: aws.String(s.Key)}
+	if s.VersionID != "" {
+		in.VersionId = aws.String(s.VersionID)
+	}
+	if s.data != nil && s.etag != "" {
+		in.IfNoneMatch = aws.String(s.etag)
+	}
+	out, err := s.Client.GetObject(ctx, in)
+	if err != nil {
+		var re interface{ HTTPStatusCode() int }
+		if s.data != nil && errors.As(err, &re) && re.HTTPStatusCode() == http.StatusNotModified {
+			return config.Decode(s.Key, s.data)
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	r := io.Reader(out.Body)
+	if config.MaxFileSize > 0 {
+		// one byte more than the limit, so Decode reports oversized objects
+		r = io.LimitReader(r, config.MaxFileSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := config.Decode(s.Key, data)
+	if err != nil {
+		return nil, err
+	}
+	s.data, s.etag = data, aws.ToString(out.ETag)
+	return doc, nil
+}
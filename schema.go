@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// schemaDialect is the JSON Schema dialect of the documents generated by ExportSchema.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportSchema returns a JSON Schema describing every key with a default or a description.
+// Dot separated key paths become nested object schemas, the type of a key is derived from its
+// default value and descriptions are included. The output is deterministic and documents
+// produced by GenerateExample validate against it.
+func (c *Configuration) ExportSchema() ([]byte, error) {
+	root := objectSchema()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, key := range sortedKeys(c.declaredKeys()) {
+		parts := strings.Split(key, ".")
+		node := root
+		for i, part := range parts[:len(parts)-1] {
+			props := node["properties"].(map[string]any)
+			sub, ok := props[part].(map[string]any)
+			if !ok {
+				sub = objectSchema()
+				props[part] = sub
+			} else if _, isObject := sub["properties"]; !isObject {
+				return nil, fmt.Errorf("config: key %q conflicts with key %q", key, strings.Join(parts[:i+1], "."))
+			}
+			node = sub
+		}
+
+		prop := make(map[string]any)
+		if val, ok := c.defaults[key]; ok {
+			if t := typeName(val); t != "" {
+				prop["type"] = t
+			}
+			prop["default"] = val
+		}
+		if desc, ok := c.descs[key]; ok {
+			prop["description"] = desc
+		}
+		node["properties"].(map[string]any)[parts[len(parts)-1]] = prop
+	}
+	root["$schema"] = schemaDialect
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// objectSchema returns a new schema for an object without properties.
+func objectSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": make(map[string]any),
+	}
+}
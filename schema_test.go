@@ -0,0 +1,76 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestExportSchemaExampleRoundTrip(t *testing.T) {
+	c := New()
+	c.SetDefault("server.host", "localhost")
+	c.SetDefault("server.port", 8080)
+	c.SetDefault("server.timeout", 2.5)
+	c.SetDefault("debug", false)
+	c.SetDefault("tags", []any{"a", "b"})
+	c.Describe("server.port", "port to listen on")
+	c.Describe("token", "API token")
+
+	data, err := c.ExportSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != schemaDialect {
+		t.Fatalf("got dialect %v, want %s", schema["$schema"], schemaDialect)
+	}
+
+	var buf bytes.Buffer
+	if err := c.GenerateExample(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ValidateBytes(buf.Bytes()); err != nil {
+		t.Fatalf("example does not validate: %v\n%s", err, buf.Bytes())
+	}
+	var doc any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, "", schema, doc)
+}
+
+// checkSchema checks that val conforms to the subset of JSON Schema produced by ExportSchema.
+func checkSchema(t *testing.T, key string, schema map[string]any, val any) {
+	t.Helper()
+	if want, ok := schema["type"].(string); ok && val != nil {
+		got := typeName(val)
+		if f, ok := val.(float64); ok && f == math.Trunc(f) && want == "integer" {
+			got = want
+		}
+		if got != want && !(want == "number" && got == "integer") {
+			t.Errorf("%s: got %s, schema requires %s", key, got, want)
+		}
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	obj, _ := val.(map[string]any)
+	for name, v := range obj {
+		sub, ok := props[name].(map[string]any)
+		if !ok {
+			t.Errorf("%s: property %q is not in the schema", key, name)
+			continue
+		}
+		checkSchema(t, key+"."+name, sub, v)
+	}
+	for name := range props {
+		if _, ok := obj[name]; !ok {
+			t.Errorf("%s: property %q of the schema is missing", key, name)
+		}
+	}
+}
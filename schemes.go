@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = make(map[string]func(u *url.URL) (Source, error)) // source openers by URL scheme
+)
+
+func init() {
+	openHTTP := func(u *url.URL) (Source, error) {
+		return NewHTTPSource(u.String()), nil
+	}
+	schemes["http"] = openHTTP
+	schemes["https"] = openHTTP
+	schemes["volume"] = func(u *url.URL) (Source, error) {
+		return NewVolumeSource(u.Path), nil
+	}
+	schemes["secrets"] = func(u *url.URL) (Source, error) {
+		return NewSecretsSource(u.Path), nil
+	}
+}
+
+// RegisterSource registers open to create the sources for URLs with the given scheme, so
+// OpenSource and ReadSource accept them. This lets packages plug in their own sources, which
+// may also implement Provider to push changes. The built-in schemes are "http" and "https" for
+// HTTPSource, "volume" for VolumeSource and "secrets" for SecretsSource, such as
+// "volume:///etc/myapp". Schemes are matched case-insensitively and registering a scheme again
+// replaces its opener. RegisterSource is safe for concurrent use.
+func RegisterSource(scheme string, open func(u *url.URL) (Source, error)) {
+	schemesMu.Lock()
+	schemes[strings.ToLower(scheme)] = open
+	schemesMu.Unlock()
+}
+
+// SourceSchemes returns the registered URL schemes in lexical order.
+func SourceSchemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	return sortedKeys(schemes)
+}
+
+// OpenSource creates the source for rawURL with the opener registered for its scheme.
+func OpenSource(rawURL string) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	schemesMu.RLock()
+	open, ok := schemes[strings.ToLower(u.Scheme)]
+	schemesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config: no source registered for scheme %q of %s", u.Scheme, rawURL)
+	}
+	src, err := open(u)
+	if err != nil {
+		return nil, fmt.Errorf("config: opening %s: %w", rawURL, err)
+	}
+	return src, nil
+}
+
+// ReadSource is like Configuration.ReadSource for the global configuration.
+func ReadSource(rawURL string) error {
+	return config.ReadSource(rawURL)
+}
+
+// ReadSource opens the source for rawURL and loads its document into c. Use OpenSource together
+// with StartRefresh or StartWatch to keep the configuration updated.
+func (c *Configuration) ReadSource(rawURL string) error {
+	src, err := OpenSource(rawURL)
+	if err != nil {
+		return err
+	}
+	return c.LoadFrom(src)
+}
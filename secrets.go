@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretsDir is the directory Docker and Docker Swarm mount secrets into.
+const DefaultSecretsDir = "/run/secrets"
+
+// SecretsSource is a Source reading secrets stored in files, such as Docker secrets. Every
+// regular file in Dir sets the key named like the file to its content without a trailing
+// newline; hidden files and subdirectories are skipped and a missing directory provides no
+// keys.
+//
+// With Env set, the source also follows the convention of pointing environment variables whose
+// name ends in "_FILE" at secret files: the variable DB_PASSWORD_FILE=/path/to/file sets the
+// key "db_password" to the content of the file, overriding a file of that name in Dir. Since
+// unrelated variables may end in "_FILE" as well, EnvPrefix restricts the variables to those
+// starting with it; the prefix is not part of the key.
+type SecretsSource struct {
+	Dir       string
+	Env       bool
+	EnvPrefix string
+}
+
+// NewSecretsSource returns a SecretsSource reading the secret files in dir, DefaultSecretsDir if
+// dir is empty.
+func NewSecretsSource(dir string) *SecretsSource {
+	if dir == "" {
+		dir = DefaultSecretsDir
+	}
+	return &SecretsSource{Dir: dir}
+}
+
+// NewCredentialsSource returns a SecretsSource reading the credentials systemd passes to a
+// service with LoadCredential, SetCredential and related settings. Each credential sets the key
+// named by its ID. It fails if the service was not started with credentials.
+func NewCredentialsSource() (*SecretsSource, error) {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return nil, errors.New("config: CREDENTIALS_DIRECTORY is not set")
+	}
+	return &SecretsSource{Dir: dir}, nil
+}
+
+// Name returns the directory.
+func (s *SecretsSource) Name() string {
+	return s.Dir
+}
+
+// Load reads the secret files.
+func (s *SecretsSource) Load(ctx context.Context) (map[string]any, error) {
+	doc := make(map[string]any)
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(s.Dir, e.Name())
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		data, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		doc[e.Name()] = fileValue(data)
+	}
+	if !s.Env {
+		return doc, nil
+	}
+
+	for _, kv := range os.Environ() {
+		name, path, _ := strings.Cut(kv, "=")
+		key, ok := strings.CutPrefix(name, s.EnvPrefix)
+		if ok {
+			key, ok = strings.CutSuffix(key, "_FILE")
+		}
+		if !ok || key == "" || path == "" {
+			continue
+		}
+		data, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading secret file of %s: %w", name, err)
+		}
+		doc[strings.ToLower(key)] = fileValue(data)
+	}
+	return doc, nil
+}
@@ -0,0 +1,110 @@
+// Package secretsmanagersource provides a configuration provider reading a JSON secret from AWS
+// Secrets Manager, so credentials need not be stored in files:
+//
+//	src := secretsmanagersource.New(secretsmanager.NewFromConfig(awsCfg), "prod/myapp")
+//	src.Key = "db"
+//	err := config.Config().StartWatch(ctx, src)
+//
+// The secret string must hold a JSON object. Its keys are merged into the configuration at the
+// top level, or below Key if it is set. Loading the source once, or periodically with
+// config.StartRefresh, merges the current version; watching it polls the secret every Interval
+// and only reports a new document when the secret was rotated to another version.
+package secretsmanagersource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// API is the part of the Secrets Manager client used by Source, implemented by
+// *secretsmanager.Client.
+type API interface {
+	GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// Source is a config.Provider reading a JSON secret from AWS Secrets Manager.
+type Source struct {
+	Client   API
+	SecretID string        // name or ARN of the secret
+	Key      string        // dot separated key the secret is stored at, empty for the top level
+	Interval time.Duration // polling interval for rotation detection, 0 for five minutes
+
+	mu      sync.Mutex
+	version string // version ID of the last read
+}
+
+// New returns a Source reading the secret secretID using client.
+func New(client API, secretID string) *Source {
+	return &Source{Client: client, SecretID: secretID}
+}
+
+// Name identifies the source by its secret ID.
+func (s *Source) Name() string {
+	return "secretsmanager:" + s.SecretID
+}
+
+// Load reads and decodes the current version of the secret.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	doc, _, err := s.read(ctx)
+	return doc, err
+}
+
+// Watch polls the secret every Interval and reports the document whenever its version changed.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			doc, rotated, err := s.read(ctx)
+			if err != nil {
+				return err
+			}
+			if rotated {
+				fn(doc)
+			}
+		}
+	}
+}
+
+// read fetches the secret and reports whether its version differs from the last read.
+func (s *Source) read(ctx context.Context) (map[string]any, bool, error) {
+	out, err := s.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(s.SecretID)})
+	if err != nil {
+		return nil, false, err
+	}
+	if out.SecretString == nil {
+		return nil, false, errors.New("secret has no string value")
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &doc); err != nil {
+		return nil, false, fmt.Errorf("decoding secret: %w", err)
+	}
+	if s.Key != "" {
+		if doc, err = config.Nest(map[string]any{s.Key: doc}, "."); err != nil {
+			return nil, false, err
+		}
+	}
+
+	version := aws.ToString(out.VersionId)
+	s.mu.Lock()
+	rotated := version != s.version
+	s.version = version
+	s.mu.Unlock()
+	return doc, rotated, nil
+}
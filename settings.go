@@ -0,0 +1,75 @@
+package config
+
+import "strconv"
+
+// AllSettings returns a deep copy of the effective configuration merged with the defaults of
+// the keys that are not set. Defaults of key paths such as "server.port" are stored inside the
+// objects along their path, which are created as needed.
+func (c *Configuration) AllSettings() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.allSettings()
+}
+
+// allSettings implements AllSettings. The caller must hold c.mu.
+func (c *Configuration) allSettings() map[string]any {
+	delim := c.delimiter()
+	res := deepCopyMap(resolveAll(c.keyvals))
+	if res == nil {
+		res = make(map[string]any)
+	}
+	for _, key := range sortedKeys(c.defaults) {
+		if _, ok := findPath(res, key, delim); ok {
+			continue
+		}
+		val := deepCopy(c.defaults[key])
+		if !setIn(res, indexPath(key, delim), delim, val) {
+			res[key] = val
+		}
+	}
+	return res
+}
+
+// Keys returns the paths of all values of AllSettings in lexical order, descending into nested
+// objects but not into arrays, joined with the key delimiter, such as "server.http.port".
+func (c *Configuration) Keys() []string {
+	c.mu.RLock()
+	delim := c.delimiter()
+	flat := Flatten(c.allSettings(), delim)
+	c.mu.RUnlock()
+	return sortedKeys(flat)
+}
+
+// Walk calls fn for every key of AllSettings in lexical order, for objects before the keys nested
+// in them and for arrays before their elements, with keys joined with the key delimiter, such as
+// "server", "server.hosts" and "server.hosts.0". Walk stops when fn returns false. The values are
+// taken from a copy of the configuration made under the read lock, so fn sees a consistent state
+// and may call methods of c, including ones changing it.
+func (c *Configuration) Walk(fn func(key string, val any) bool) {
+	c.mu.RLock()
+	delim := c.delimiter()
+	settings := c.allSettings()
+	c.mu.RUnlock()
+	walk("", settings, delim, fn)
+}
+
+// walk calls fn for the keys of the object or array node below prefix and reports whether fn
+// asked to continue.
+func walk(prefix string, node any, delim string, fn func(key string, val any) bool) bool {
+	switch n := node.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(n) {
+			if !fn(prefix+key, n[key]) || !walk(prefix+key+delim, n[key], delim, fn) {
+				return false
+			}
+		}
+	case []any:
+		for i, val := range n {
+			key := prefix + strconv.Itoa(i)
+			if !fn(key, val) || !walk(key+delim, val, delim, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
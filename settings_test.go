@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestAllSettings(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"server": {"port": 80, "hosts": ["a", "b"]}, "name": "app"}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("server.timeout", 5)
+	c.SetDefault("server.port", 8080)
+	c.SetDefault("debug", false)
+
+	want := map[string]any{
+		"server": map[string]any{"port": 80.0, "hosts": []any{"a", "b"}, "timeout": 5},
+		"name":   "app",
+		"debug":  false,
+	}
+	all := c.AllSettings()
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("got %v, want %v", all, want)
+	}
+	all["server"].(map[string]any)["hosts"].([]any)[0] = "changed"
+	if got := c.GetStr("server.hosts.0"); got != "a" {
+		t.Errorf("changing the result changed the configuration: got %q", got)
+	}
+
+	if want := []string{"debug", "name", "server.hosts", "server.port", "server.timeout"}; !slices.Equal(c.Keys(), want) {
+		t.Errorf("got keys %v, want %v", c.Keys(), want)
+	}
+	c.SetKeyDelimiter("/")
+	if got := c.Keys(); !slices.Contains(got, "server/port") {
+		t.Errorf("got keys %v, want them joined with the key delimiter", got)
+	}
+	if got := New().Keys(); len(got) != 0 {
+		t.Errorf("empty configuration: got keys %v", got)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"server": {"hosts": ["a", {"name": "b"}], "port": 80}, "name": "app"}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("debug", true)
+
+	var keys []string
+	c.Walk(func(key string, val any) bool {
+		keys = append(keys, key)
+		return true
+	})
+	want := []string{"debug", "name", "server", "server.hosts", "server.hosts.0", "server.hosts.1", "server.hosts.1.name", "server.port"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+
+	keys = nil
+	c.Walk(func(key string, val any) bool {
+		keys = append(keys, key)
+		return key != "server.hosts"
+	})
+	if want := want[:4]; !slices.Equal(keys, want) {
+		t.Errorf("got keys %v, want Walk to stop at server.hosts", keys)
+	}
+
+	// fn may change the configuration it walks
+	c.Walk(func(key string, val any) bool {
+		if key == "name" {
+			c.Set("name", "changed")
+		}
+		return true
+	})
+	if got := c.GetStr("name"); got != "changed" {
+		t.Errorf("got name %q after changing it while walking", got)
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshotVersion is the version of the binary snapshot format written by WriteSnapshot.
+const snapshotVersion = 1
+
+// snapshot is the binary representation of a configuration written by WriteSnapshot.
+type snapshot struct {
+	Version    int
+	SourceHash string
+	Inputs     []string // names of the files read by ReadFileCached, see readInputs
+	Keyvals    map[string]any
+}
+
+func init() {
+	// nested JSON values are stored as interface values and must be known to gob
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+// Snapshot is an immutable copy of the values of a configuration at one point in time, taken
+// with Configuration.Snapshot and rolled back to with Configuration.Restore.
+type Snapshot struct {
+	version     int64
+	taken       time.Time
+	delim       string
+	keyvals     map[string]any // effective configuration
+	layers      *layers        // nil if the configuration had no layers
+	profile     string
+	profileBase map[string]any
+	profiles    map[string]map[string]any
+	srcHash     string
+}
+
+// Snapshot returns a snapshot of the values of all layers of c and its selected profile, so a
+// bad change made at runtime can later be reverted with Restore.
+func (c *Configuration) Snapshot() *Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.takeSnapshot()
+}
+
+// takeSnapshot implements Snapshot. The caller must hold c.mu.
+func (c *Configuration) takeSnapshot() *Snapshot {
+	s := &Snapshot{
+		version:     c.version,
+		taken:       time.Now(),
+		delim:       c.delimiter(),
+		layers:      c.layers.clone(),
+		profile:     c.profile,
+		profileBase: deepCopyMap(c.profileBase),
+		profiles:    cloneProfiles(c.profiles),
+		srcHash:     c.srcHash,
+	}
+	if s.layers != nil {
+		s.keyvals = s.layers.compose()
+	} else {
+		s.keyvals = deepCopyMap(c.keyvals)
+	}
+	return s
+}
+
+// Restore rolls c back to the values of snap, replacing the values of all layers and the
+// selected profile, as a new version. Defaults, bindings, settings and the files c was read from
+// are unchanged. The change is applied atomically and notified to change and reload callbacks. A
+// snapshot may be restored any number of times.
+func (c *Configuration) Restore(snap *Snapshot) {
+	if err := c.restore(snap); err != nil {
+		c.rejected(err)
+	}
+}
+
+// restore implements Restore. It fails if c is frozen.
+func (c *Configuration) restore(snap *Snapshot) error {
+	c.mu.Lock()
+	if err := c.checkFrozen(""); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	old := c.keyvals
+	c.layers = snap.layers.clone()
+	if c.layers != nil {
+		c.keyvals = c.layers.compose()
+	} else if c.keyvals = deepCopyMap(snap.keyvals); c.keyvals == nil {
+		c.keyvals = make(map[string]any)
+	}
+	c.profile = snap.profile
+	c.profileBase = deepCopyMap(snap.profileBase)
+	c.profiles = cloneProfiles(snap.profiles)
+	c.srcHash = snap.srcHash
+	c.record()
+	new := c.keyvals
+	c.mu.Unlock()
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, true))
+	}
+	return nil
+}
+
+// Version returns the version of the configuration the snapshot was taken of, see
+// Configuration.Version.
+func (s *Snapshot) Version() int64 {
+	return s.version
+}
+
+// Time returns the time the snapshot was taken.
+func (s *Snapshot) Time() time.Time {
+	return s.taken
+}
+
+// Get retrieves a value from the snapshot by key like Configuration.Get, without defaults.
+func (s *Snapshot) Get(key string) (any, bool) {
+	val, ok := findPath(s.keyvals, key, s.delim)
+	return deepCopy(val), ok
+}
+
+// AllSettings returns a deep copy of the effective values of the snapshot.
+func (s *Snapshot) AllSettings() map[string]any {
+	return deepCopyMap(s.keyvals)
+}
+
+// WriteSnapshot writes the configuration to w in a binary format that is faster to load than
+// the source document. The snapshot records the hash of the document the configuration was
+// loaded from so ReadFileCached can detect stale snapshots.
+func (c *Configuration) WriteSnapshot(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(&snapshot{
+		Version:    snapshotVersion,
+		SourceHash: c.srcHash,
+		Keyvals:    resolveAll(c.persisted()),
+	})
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot and updates the configuration.
+func (c *Configuration) ReadSnapshot(r io.Reader) error {
+	snap, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	return c.merge(snap.Keyvals, nil, snap.SourceHash)
+}
+
+// readSnapshot decodes a snapshot and checks its format version.
+func readSnapshot(r io.Reader) (*snapshot, error) {
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(r)).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("config: invalid snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("config: unsupported snapshot version %d", snap.Version)
+	}
+	return &snap, nil
+}
+
+// ReadFileCached reads a configuration file and updates the global configuration like
+// ReadFile, using the snapshot file cache to skip parsing when the snapshot was written from the
+// current content of fname, its profile overlay file and the files they include, with the same
+// facts for conditional sections. Missing, corrupt, outdated or stale snapshots are ignored and
+// the snapshot is rewritten from the parsed files. Failures to write the snapshot are ignored as
+// well.
+func ReadFileCached(fname, cache string) *Configuration {
+	if err := config.readFileCached(fname, cache); err != nil {
+		panic(err)
+	}
+	return &config
+}
+
+// readFileCached implements ReadFileCached for c.
+func (c *Configuration) readFileCached(fname, cache string) error {
+	files := layeredFiles(fname, "", c.profileOverlays(fname))
+	if f, err := os.Open(cache); err == nil {
+		snap, err := readSnapshot(f)
+		f.Close()
+		if err == nil {
+			if inputs, ok := cachedInputs(snap, files); ok && cacheKey(inputs) == snap.SourceHash {
+				return c.merge(snap.Keyvals, files, inputsHash(inputs))
+			}
+		}
+	}
+
+	keyvals, inputs, err := c.readInputs(files)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(inputs))
+	for i, input := range inputs {
+		names[i] = input[:strings.LastIndexByte(input, ':')]
+	}
+	writeSnapshotFile(&snapshot{
+		Version:    snapshotVersion,
+		SourceHash: cacheKey(inputs),
+		Inputs:     names,
+		Keyvals:    resolveAll(keyvals),
+	}, cache)
+	return c.merge(keyvals, files, inputsHash(inputs))
+}
+
+// cachedInputs returns the current inputs of the files the snapshot snap was read from, as
+// readInputs does, and updates the present flags of files. It fails if a file cannot be read or
+// an optional file of files appeared or disappeared since the snapshot was written.
+func cachedInputs(snap *snapshot, files []fileSource) ([]string, bool) {
+	if len(snap.Inputs) == 0 {
+		return nil, false
+	}
+	read := make(map[string]bool, len(snap.Inputs))
+	inputs := make([]string, len(snap.Inputs))
+	for i, name := range snap.Inputs {
+		data, err := readFile(name)
+		if err != nil {
+			return nil, false
+		}
+		inputs[i] = name + ":" + hashOf(data)
+		read[name] = true
+	}
+	for i := range files {
+		_, err := os.Stat(files[i].name)
+		files[i].present = err == nil
+		if files[i].present != read[files[i].name] {
+			return nil, false
+		}
+	}
+	return inputs, true
+}
+
+// cacheKey returns the hash identifying the result of reading inputs, as returned by readInputs,
+// which also covers the current values of the facts conditional sections are evaluated against.
+func cacheKey(inputs []string) string {
+	lines := slices.Clone(inputs)
+	facts.RLock()
+	for _, name := range sortedKeys(facts.m) {
+		lines = append(lines, "fact "+name+"="+facts.m[name]())
+	}
+	facts.RUnlock()
+	return hashOf([]byte(strings.Join(lines, "\n")))
+}
+
+// writeSnapshotFile atomically replaces the snapshot file cache with snap.
+func writeSnapshotFile(snap *snapshot, cache string) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return err
+	}
+	return writeFileAtomic(cache, buf.Bytes(), 0o644)
+}
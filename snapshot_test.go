@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFileCachedInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "extra.json", `{"extra": 1}`)
+	fname := writeFile(t, dir, "app.json", `{
+		"$include": "extra.json",
+		"$when": [{"cache_test": "b", "set": {"fact": "b"}}]
+	}`)
+	cache := filepath.Join(dir, "app.snapshot")
+	setFact(t, "cache_test", "a")
+
+	read := func() *Configuration {
+		t.Helper()
+		c := New()
+		if err := c.readFileCached(fname, cache); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	if got := read().GetInt("extra"); got != 1 {
+		t.Fatalf("got extra %d on a cache miss, want 1", got)
+	}
+	if got := read().GetInt("extra"); got != 1 {
+		t.Fatalf("got extra %d on a cache hit, want 1", got)
+	}
+
+	writeFile(t, dir, "extra.json", `{"extra": 2}`)
+	if got := read().GetInt("extra"); got != 2 {
+		t.Fatalf("got extra %d after changing an included file, want 2", got)
+	}
+
+	setFact(t, "cache_test", "b")
+	if got := read().GetStr("fact"); got != "b" {
+		t.Fatalf("got fact %q after changing a fact, want b", got)
+	}
+
+	t.Setenv(ProfileEnv, "prod")
+	writeFile(t, dir, "app.prod.json", `{"extra": 3}`)
+	c := read()
+	if got := c.GetInt("extra"); got != 3 {
+		t.Fatalf("got extra %d after adding a profile overlay, want 3", got)
+	}
+	if got := c.Sources(); len(got) != 2 {
+		t.Fatalf("got sources %q, want the file and its overlay", got)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("extra"); got != 3 {
+		t.Fatalf("got extra %d after a reload, want 3", got)
+	}
+}
+
+// largeDocument writes a JSON document with n objects of a few values each to dir and returns
+// its name.
+func largeDocument(b *testing.B, dir string, n int) string {
+	b.Helper()
+	doc := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		doc[fmt.Sprintf("service%d", i)] = map[string]any{
+			"host":    fmt.Sprintf("host%d.example.com", i),
+			"port":    8000 + i,
+			"enabled": i%2 == 0,
+			"tags":    []any{"a", "b", "c"},
+		}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	fname := filepath.Join(dir, "large.json")
+	if err := os.WriteFile(fname, data, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	return fname
+}
+
+func BenchmarkReadFileCached(b *testing.B) {
+	dir := b.TempDir()
+	fname := largeDocument(b, dir, 10000)
+	cache := filepath.Join(dir, "large.snapshot")
+
+	b.Run("parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := New().ReadFile(fname); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("snapshot", func(b *testing.B) {
+		if err := New().readFileCached(fname, cache); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := New().readFileCached(fname, cache); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	t.Setenv("APP_PORT", "7070")
+	c := New()
+	if err := c.LoadBytes([]byte(`{"port": 80, "server": {"hosts": ["a"]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("name", "before")
+	snap := c.Snapshot()
+	if snap.Version() != c.Version() {
+		t.Errorf("got snapshot version %d, want %d", snap.Version(), c.Version())
+	}
+
+	c.Set("name", "after")
+	c.Set("server.hosts.0", "b")
+	c.Delete("port")
+	if err := c.AutomaticEnv("app"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := snap.Get("name"); got != "before" {
+		t.Errorf("the snapshot changed with c: got name %v", got)
+	}
+	hosts, _ := snap.Get("server.hosts")
+	hosts.([]any)[0] = "changed"
+	if got, _ := snap.Get("server.hosts.0"); got != "a" {
+		t.Errorf("the snapshot is not immutable: got %v", got)
+	}
+
+	var reloaded bool
+	c.OnReload(func([]Change) { reloaded = true })
+	version := c.Version()
+	c.Restore(snap)
+	if got := fmt.Sprintf("%s %s %d", c.GetStr("name"), c.GetStr("server.hosts.0"), c.GetInt("port")); got != "before a 80" {
+		t.Errorf("after Restore: got %q", got)
+	}
+	if c.Version() != version+1 {
+		t.Errorf("got version %d, want Restore to make version %d", c.Version(), version+1)
+	}
+	if !reloaded {
+		t.Error("Restore did not notify reload callbacks")
+	}
+
+	c.Set("name", "again")
+	c.Restore(snap)
+	if got := c.GetStr("name"); got != "before" {
+		t.Errorf("after restoring again: got %q", got)
+	}
+}
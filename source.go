@@ -0,0 +1,81 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Source provides configuration documents from outside the file system, such as remote
+// configuration services.
+type Source interface {
+	// Name identifies the source in error messages.
+	Name() string
+	// Load fetches the current configuration document, honoring the deadline and cancellation
+	// of ctx.
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// LoadFrom loads the configuration document provided by src into the configuration.
+func (c *Configuration) LoadFrom(src Source) error {
+	return c.LoadFromCtx(context.Background(), src)
+}
+
+// LoadFromCtx is like LoadFrom but passes ctx to the source.
+func (c *Configuration) LoadFromCtx(ctx context.Context, src Source) error {
+	keyvals, err := src.Load(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return fmt.Errorf("config: loading from %s: %w", src.Name(), err)
+	}
+	return c.merge(keyvals, nil, "")
+}
+
+// ParseValue returns data decoded as JSON if it is a valid JSON document and as a string
+// otherwise. Sources reading untyped values, such as the values of key-value stores, use it so
+// "8080" becomes a number and {"a": 1} an object while plain text stays a string.
+func ParseValue(data []byte) any {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return string(data)
+	}
+	return v
+}
+
+// ReadURL is like Configuration.ReadURL for the global configuration.
+func ReadURL(url string) error {
+	return config.ReadURL(url)
+}
+
+// ReadURL fetches a configuration document from url with an HTTP GET request and updates c. The
+// document is decoded like HTTPSource decodes it, in the format registered for the extension of
+// the URL path, JSON by default, but lazily if enabled with SetLazyDecoding. Documents larger
+// than MaxFileSize are rejected.
+func (c *Configuration) ReadURL(url string) error {
+	return c.ReadURLCtx(context.Background(), url)
+}
+
+// ReadURLCtx is like Configuration.ReadURLCtx for the global configuration.
+func ReadURLCtx(ctx context.Context, url string) error {
+	return config.ReadURLCtx(ctx, url)
+}
+
+// ReadURLCtx is like ReadURL but the request is bound to ctx.
+func (c *Configuration) ReadURLCtx(ctx context.Context, url string) error {
+	name, _, _ := strings.Cut(url, "?")
+	var sum string
+	keyvals, err := NewHTTPSource(url).load(ctx, func(data []byte) (map[string]any, error) {
+		sum = hashOf(data)
+		return c.decode(name, data)
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return fmt.Errorf("config: reading %s: %w", url, err)
+	}
+	return c.merge(keyvals, nil, sum)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	for in, want := range map[string]any{
+		"8080":         8080.0,
+		"true":         true,
+		`"quoted"`:     "quoted",
+		`{"a": [1]}`:   map[string]any{"a": []any{1.0}},
+		"null":         nil,
+		"plain text":   "plain text",
+		"":             "",
+		`{"broken":`:   `{"broken":`,
+		"8080 and up":  "8080 and up",
+		" 42 ":         42.0,
+		"postgres://x": "postgres://x",
+	} {
+		if got := ParseValue([]byte(in)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %#v, want %#v", in, got, want)
+		}
+	}
+}
+
+func TestReadURLAndHTTPSourceDecodeAlike(t *testing.T) {
+	setFact(t, "env", "prod")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, `{"db": {"host": "localhost"}, "$when": [{"env": "prod", "set": {"db": {"host": "db.prod"}}}]}`)
+	}))
+	defer srv.Close()
+
+	src := NewHTTPSource(srv.URL + "/app.json?v=1")
+	for i := range 2 {
+		doc, err := src.Load(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := doc["db"].(map[string]any)["host"]; got != "db.prod" {
+			t.Errorf("HTTPSource load %d: got host %v, want the conditional section applied", i, got)
+		}
+		if _, ok := doc["$when"]; ok {
+			t.Errorf("HTTPSource load %d: the conditional sections were kept", i)
+		}
+		doc["db"] = "changed"
+	}
+
+	for _, lazy := range []bool{false, true} {
+		c := New()
+		c.SetLazyDecoding(lazy)
+		if err := c.ReadURL(srv.URL + "/app.json"); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.GetStr("db.host"); got != "db.prod" || c.Exists("$when") {
+			t.Errorf("ReadURL with lazy decoding %t: got host %q, want the conditional section applied", lazy, got)
+		}
+	}
+
+	if err := New().ReadURL(srv.URL + "/missing\x7f"); err == nil {
+		t.Error("read an invalid URL")
+	}
+}
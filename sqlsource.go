@@ -0,0 +1,81 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// SQLSource is a Source loading key-value rows from a SQL database, such as a settings table
+// managed through an admin interface. Query must return two columns, the dot separated key and
+// its value, decoded with ParseValue. Combined
+// with StartRefresh it polls the table for changes.
+//
+// If ChangeQuery is set, it must return a single value that changes whenever the rows do, such
+// as the maximum of an update timestamp column. Load then only runs Query when that value
+// differs from the one seen at the last load, which keeps frequent polling cheap.
+type SQLSource struct {
+	DB          *sql.DB
+	Query       string
+	ChangeQuery string
+
+	mu      sync.Mutex
+	version any
+	doc     map[string]any
+}
+
+// NewSQLSource returns an SQLSource loading the rows returned by query from db.
+func NewSQLSource(db *sql.DB, query string) *SQLSource {
+	return &SQLSource{DB: db, Query: query}
+}
+
+// Name returns the query.
+func (s *SQLSource) Name() string {
+	return "sql: " + s.Query
+}
+
+// Load runs the query, or returns the last document if the change query reports no change.
+func (s *SQLSource) Load(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var version any
+	if s.ChangeQuery != "" {
+		if err := s.DB.QueryRowContext(ctx, s.ChangeQuery).Scan(&version); err != nil {
+			return nil, err
+		}
+		if s.doc != nil && reflect.DeepEqual(version, s.version) {
+			return deepCopyMap(s.doc), nil
+		}
+	}
+
+	rows, err := s.DB.QueryContext(ctx, s.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	flat := make(map[string]any)
+	for rows.Next() {
+		var key string
+		var val sql.NullString
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, err
+		}
+		if !val.Valid {
+			flat[key] = nil
+			continue
+		}
+		flat[key] = ParseValue([]byte(val.String))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := Nest(flat, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%w in query result", err)
+	}
+	s.version, s.doc = version, doc
+	return deepCopyMap(doc), nil
+}
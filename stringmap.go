@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AsStringMap returns a copy of the configuration, including defaults, flattened into dot
+// separated keys with values converted by ConvertTo[string]. Arrays of scalar values are joined
+// with commas and null values become empty strings; values that cannot be represented as a
+// string, such as arrays of objects, are skipped. If prefix is not empty only keys below prefix
+// are returned, with the prefix and its separating dot removed.
+func (c *Configuration) AsStringMap(prefix string) map[string]string {
+	m, _ := c.asStringMap(prefix, false)
+	return m
+}
+
+// AsStringMapStrict is like AsStringMap but fails on values that cannot be represented as a
+// string instead of skipping them.
+func (c *Configuration) AsStringMapStrict(prefix string) (map[string]string, error) {
+	return c.asStringMap(prefix, true)
+}
+
+// asStringMap implements AsStringMap and AsStringMapStrict.
+func (c *Configuration) asStringMap(prefix string, strict bool) (map[string]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// the values are merged over a copy of the defaults, so nested defaults are kept next to the
+	// values of the objects they are nested in
+	merged := make(map[string]any)
+	for _, key := range sortedKeys(c.defaults) {
+		setPath(merged, strings.Split(key, c.delimiter()), deepCopy(c.defaults[key]))
+	}
+	deepMerge(merged, c.keyvals)
+	flat := make(map[string]string)
+	for key, val := range merged {
+		if err := flattenString(flat, key, val, strict); err != nil {
+			return nil, err
+		}
+	}
+
+	if prefix == "" {
+		return flat, nil
+	}
+	sub := make(map[string]string)
+	for key, val := range flat {
+		if rest, ok := strings.CutPrefix(key, prefix+"."); ok {
+			sub[rest] = val
+		}
+	}
+	return sub, nil
+}
+
+// flattenString stores the string form of val in flat under key, descending into nested maps.
+func flattenString(flat map[string]string, key string, val any, strict bool) error {
+	switch v := val.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			if err := flattenString(flat, key+"."+k, sub, strict); err != nil {
+				return err
+			}
+		}
+	case []any:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			switch elem.(type) {
+			case map[string]any, []any:
+				if strict {
+					return &KeyError{Key: key, Err: fmt.Errorf("%w: array of composite values", ErrTypeMismatch)}
+				}
+				return nil
+			}
+			elems[i] = scalarString(elem)
+		}
+		flat[key] = strings.Join(elems, ",")
+	default:
+		flat[key] = scalarString(v)
+	}
+	return nil
+}
+
+// scalarString converts a scalar configuration value to its string form.
+func scalarString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string, int, int64, float64, bool:
+		return ConvertTo[string](v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestAsStringMapNestedDefaults(t *testing.T) {
+	c := New()
+	c.SetDefault("db.port", 5432)
+	c.SetDefault("db.pool", map[string]any{"size": 10, "idle": 2})
+	c.SetDefault("tags", []any{"a", "b"})
+	c.Set("db", map[string]any{"host": "localhost", "pool": map[string]any{"size": 20}})
+
+	want := map[string]string{
+		"db.host":      "localhost",
+		"db.port":      "5432",
+		"db.pool.size": "20",
+		"db.pool.idle": "2",
+		"tags":         "a,b",
+	}
+	if got := c.AsStringMap(""); !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := c.AsStringMap("db.pool"); !maps.Equal(got, map[string]string{"size": "20", "idle": "2"}) {
+		t.Errorf("below db.pool: got %v", got)
+	}
+	if pool := c.defaults["db.pool"].(map[string]any); pool["size"] != 10 {
+		t.Errorf("AsStringMap modified the defaults: %v", pool)
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "github.com/pelletier/go-toml/v2"
+
+func init() {
+	RegisterFormat(".toml", decodeTOML, encodeTOML)
+}
+
+// decodeTOML decodes a TOML document into a key-value map. Tables are decoded as nested objects
+// and arrays of tables as arrays of objects; integers are kept as int64 and dates and times are
+// converted to their RFC 3339 representation.
+func decodeTOML(data []byte) (map[string]any, error) {
+	keyvals := make(map[string]any)
+	if err := toml.Unmarshal(data, &keyvals); err != nil {
+		return nil, err
+	}
+	return normalizeValue(keyvals).(map[string]any), nil
+}
+
+// encodeTOML encodes keyvals as a TOML document.
+func encodeTOML(keyvals map[string]any) ([]byte, error) {
+	return toml.Marshal(keyvals)
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+)
+
+// Tx is a transaction of Update. Its methods read and change the configuration like the methods
+// of Configuration of the same name, but the changes are only visible inside the transaction
+// until Update applies them.
+type Tx struct {
+	view *Configuration // configuration with the changes of the transaction
+	keys []string       // keys changed by the transaction, in order
+}
+
+// UpdateOption configures how Update applies a transaction.
+type UpdateOption func(*updateOptions)
+
+// updateOptions holds the settings applied by UpdateOption values.
+type updateOptions struct {
+	validate bool
+}
+
+// WithValidation makes Update validate the top level keys changed by the transaction against the
+// declared keys like ValidateBytes and discard the transaction with a *ValidationError holding
+// the findings of severity SeverityError, if any.
+func WithValidation() UpdateOption {
+	return func(o *updateOptions) {
+		o.validate = true
+	}
+}
+
+// Update calls fn with a transaction and applies all changes fn made with it atomically if fn
+// returns nil, so readers never observe some of the changes without the others. If fn returns an
+// error, or panics, the changes are discarded and the error is returned, or the panic continues.
+// c is locked while fn runs, so fn must not call methods of c, but read and change the
+// configuration through tx only. The changes are notified to change callbacks and namespace
+// hooks once they were applied, as a single new version.
+func (c *Configuration) Update(fn func(tx *Tx) error, opts ...UpdateOption) error {
+	var o updateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var old, new map[string]any
+	tx, err := func() (*Tx, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err := c.checkFrozen(""); err != nil {
+			return nil, err
+		}
+		tx := &Tx{view: c.shadow()}
+		if err := fn(tx); err != nil || len(tx.keys) == 0 {
+			return tx, err
+		}
+		if o.validate {
+			if err := c.validateTx(tx); err != nil {
+				return nil, err
+			}
+		}
+		old = c.keyvals
+		c.layers, c.keyvals = tx.view.layers, tx.view.keyvals
+		c.record()
+		new = c.keyvals
+		return tx, nil
+	}()
+	if err != nil || len(tx.keys) == 0 {
+		return err
+	}
+
+	if c.hasSubscribers() {
+		c.notify(newDelta(old, new, false))
+	}
+	for _, key := range tx.keys {
+		c.checkNamespace(key)
+	}
+	return nil
+}
+
+// shadow returns a configuration holding the values and defaults of c whose changes do not
+// affect c. The caller must hold c.mu.
+func (c *Configuration) shadow() *Configuration {
+	s := &Configuration{
+		keyvals:  maps.Clone(c.keyvals),
+		defaults: c.defaults,
+		keyDelim: c.keyDelim,
+	}
+	if s.keyvals == nil {
+		s.keyvals = make(map[string]any)
+	}
+	if c.layers != nil {
+		s.layers = &layers{
+			files: maps.Clone(c.layers.files),
+			env:   maps.Clone(c.layers.env),
+			flags: maps.Clone(c.layers.flags),
+			set:   maps.Clone(c.layers.set),
+		}
+	}
+	return s
+}
+
+// validateTx validates the top level values changed by tx. The caller must hold c.mu.
+func (c *Configuration) validateTx(tx *Tx) error {
+	changed := make(map[string]any)
+	for key, val := range tx.view.keyvals {
+		if prev, ok := c.keyvals[key]; !ok || !reflect.DeepEqual(resolve(prev), resolve(val)) {
+			changed[key] = resolve(val)
+		}
+	}
+	var errs []Finding
+	for _, f := range c.findingsLocked(changed) {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Findings: errs}
+	}
+	return nil
+}
+
+// Get retrieves a value from the transaction by key, falling back to its default.
+func (tx *Tx) Get(key string) (any, bool) {
+	return tx.view.Get(key)
+}
+
+// Exists checks if a key exists in the transaction.
+func (tx *Tx) Exists(key string) bool {
+	return tx.view.Exists(key)
+}
+
+// Set sets a value by key in the transaction, see Configuration.Set.
+func (tx *Tx) Set(key string, val any) {
+	tx.view.set(key, val)
+	tx.keys = append(tx.keys, key)
+}
+
+// Delete removes a key in the transaction, see Configuration.Delete.
+func (tx *Tx) Delete(key string) {
+	tx.view.delete(key)
+	tx.keys = append(tx.keys, key)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"db": {"host": "a", "port": 1}, "gone": true}`)); err != nil {
+		t.Fatal(err)
+	}
+	var notified int
+	c.OnChange("db", func(old, new any) error {
+		notified++
+		return nil
+	})
+	version := c.Version()
+	err := c.Update(func(tx *Tx) error {
+		tx.Set("db.host", "b")
+		tx.Set("db.port", 2)
+		tx.Delete("gone")
+		if got, _ := tx.Get("db.host"); got != "b" {
+			t.Errorf("got db.host %v inside the transaction, want its change", got)
+		}
+		if tx.Exists("gone") {
+			t.Error("gone exists inside the transaction after deleting it")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetStr("db.host") + c.GetStr("db.port"); got != "b2" || c.Exists("gone") {
+		t.Errorf("after Update: got %q, want all changes applied", got)
+	}
+	if c.Version() != version+1 || notified != 1 {
+		t.Errorf("got %d versions and %d notifications, want a single change", c.Version()-version, notified)
+	}
+
+	errDiscard := errors.New("discard")
+	if err := c.Update(func(tx *Tx) error {
+		tx.Set("db.host", "c")
+		return errDiscard
+	}); err != errDiscard {
+		t.Errorf("got %v, want the error of fn", err)
+	}
+	func() {
+		defer func() { recover() }()
+		c.Update(func(tx *Tx) error {
+			tx.Set("db.host", "d")
+			panic("boom")
+		})
+	}()
+	if got := c.GetStr("db.host"); got != "b" {
+		t.Errorf("got db.host %q, want failed transactions discarded", got)
+	}
+
+	c.SetDefault("db.port", 80)
+	var verr *ValidationError
+	if err := c.Update(func(tx *Tx) error {
+		tx.Set("db.port", "http")
+		return nil
+	}, WithValidation()); !errors.As(err, &verr) {
+		t.Errorf("got %v, want a *ValidationError", err)
+	}
+	if got := c.GetInt("db.port"); got != 2 {
+		t.Errorf("got db.port %d, want the invalid transaction discarded", got)
+	}
+}
+
+func TestUpdateIsAtomic(t *testing.T) {
+	c := New()
+	c.SetAll(map[string]any{"a": 0, "b": 0})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			c.Update(func(tx *Tx) error {
+				tx.Set("a", i)
+				tx.Set("b", i)
+				return nil
+			})
+		}
+	}()
+	for range 100 {
+		all := c.AllSettings()
+		if all["a"] != all["b"] {
+			t.Fatalf("observed a half-applied transaction: %v", all)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,341 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// GetInto decodes the value of key, falling back to its default, into the value dst points to.
+// Values whose type is assignable to the target are assigned directly. Scalars are converted to
+// strings, integers, floats and booleans, including named types, like GetE converts them;
+// strings are decoded with UnmarshalText when the target implements encoding.TextUnmarshaler and
+// into time.Duration with time.ParseDuration. Objects are decoded into structs and maps and
+// arrays into slices and arrays, element by element. Struct fields are matched by the name in
+// their "config" tag, or else in their "json" tag, or else case-insensitively by their name;
+// fields tagged "-" are skipped. The "config" tag may add the options "default=value", decoding
+// value like a string value when the key is missing, and "required", such as in
+// `config:"port,default=8080"` and `config:"host,required"`. Decoding fails with a *KeyError
+// when key is not set or a value cannot be decoded into its target, naming the nested key that
+// failed, and with a *ValidationError listing all missing required keys.
+func (c *Configuration) GetInto(key string, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("config: GetInto requires a non-nil pointer, got %T", dst)
+	}
+	val, ok := c.GetShared(key)
+	if !ok {
+		return &KeyError{Key: key, Err: ErrKeyNotFound}
+	}
+	return newDecoder(nil).decode(key, val, rv.Elem())
+}
+
+// UnmarshalOption configures how Unmarshal and UnmarshalKey decode the configuration.
+type UnmarshalOption func(*decoder)
+
+// decoder decodes configuration values into Go values with the settings applied by
+// UnmarshalOption values.
+type decoder struct {
+	strict  bool     // fail on keys that match no struct field
+	missing []string // paths of missing required keys
+}
+
+// DisallowUnknownKeys makes Unmarshal and UnmarshalKey fail with a *KeyError matching
+// ErrUnknownKey for keys of objects decoded into structs that match no field of the struct,
+// such as the misspelled "databse", instead of ignoring them. Keys of fields tagged "-" are
+// unknown as well.
+func DisallowUnknownKeys() UnmarshalOption {
+	return func(d *decoder) {
+		d.strict = true
+	}
+}
+
+// newDecoder returns a decoder with opts applied.
+func newDecoder(opts []UnmarshalOption) *decoder {
+	d := new(decoder)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// decode decodes val, the value at path, into v and reports the missing required keys.
+func (d *decoder) decode(path string, val any, v reflect.Value) error {
+	if err := d.decodeValue(path, val, v); err != nil {
+		return err
+	}
+	if len(d.missing) == 0 {
+		return nil
+	}
+	findings := make([]Finding, len(d.missing))
+	for i, key := range d.missing {
+		findings[i] = Finding{Key: key, Severity: SeverityError, Message: "required key is not set"}
+	}
+	return &ValidationError{Findings: findings}
+}
+
+// Unmarshal decodes the whole configuration as returned by AllSettings, including the defaults
+// of keys that are not set, into the struct or map dst points to, decoding values like GetInto.
+func (c *Configuration) Unmarshal(dst any, opts ...UnmarshalOption) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("config: Unmarshal requires a non-nil pointer, got %T", dst)
+	}
+	return newDecoder(opts).decode("", c.AllSettings(), rv.Elem())
+}
+
+// UnmarshalKey is like Unmarshal for the value of key only, such as the section "database", so
+// components can decode their own section into their own type. Unlike GetInto, the defaults of
+// keys nested in the value, such as "database.port", are included. It fails with a *KeyError
+// matching ErrKeyNotFound if key is neither set nor has a default.
+func (c *Configuration) UnmarshalKey(key string, dst any, opts ...UnmarshalOption) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("config: UnmarshalKey requires a non-nil pointer, got %T", dst)
+	}
+	c.mu.RLock()
+	val, ok := findPath(c.allSettings(), key, c.delimiter())
+	c.mu.RUnlock()
+	if !ok {
+		return &KeyError{Key: key, Err: ErrKeyNotFound}
+	}
+	return newDecoder(opts).decode(key, val, rv.Elem())
+}
+
+// decodeValue decodes val, the value at the dot separated path, into v. The path of the whole
+// configuration is empty.
+func (d *decoder) decodeValue(path string, val any, v reflect.Value) error {
+	val = resolve(val)
+	if val == nil {
+		v.SetZero()
+		return nil
+	}
+	if s, ok := val.(string); ok && v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return &KeyError{Key: path, Err: fmt.Errorf("%w: %w", ErrParse, err)}
+		}
+		return nil
+	}
+	if s, ok := val.(string); ok && v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &KeyError{Key: path, Err: fmt.Errorf("%w: %w", ErrParse, err)}
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if rt := reflect.TypeOf(val); rt.AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(deepCopy(val)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := d.decodeValue(path, val, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		m, ok := val.(map[string]any)
+		if !ok {
+			return mismatch(path, val, v)
+		}
+		return d.decodeStruct(path, m, v)
+	case reflect.Map:
+		m, ok := val.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return mismatch(path, val, v)
+		}
+		res := reflect.MakeMapWithSize(v.Type(), len(m))
+		for key, elem := range m {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decodeValue(joinPath(path, key), elem, ev); err != nil {
+				return err
+			}
+			res.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), ev)
+		}
+		v.Set(res)
+		return nil
+	case reflect.Slice, reflect.Array:
+		a, ok := val.([]any)
+		if !ok {
+			return mismatch(path, val, v)
+		}
+		if v.Kind() == reflect.Array && len(a) != v.Len() {
+			return &KeyError{Key: path, Err: fmt.Errorf("%w: cannot decode array of %d elements into %s", ErrTypeMismatch, len(a), v.Type())}
+		}
+		res := v
+		if v.Kind() == reflect.Slice {
+			res = reflect.MakeSlice(v.Type(), len(a), len(a))
+		}
+		for i, elem := range a {
+			if err := d.decodeValue(fmt.Sprintf("%s[%d]", path, i), elem, res.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(res)
+		return nil
+	}
+
+	if isComposite(val) {
+		return mismatch(path, val, v)
+	}
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		var s string
+		s, err = convertE[string](val)
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = convertE[bool](val)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = convertE[int64](val); err == nil && v.OverflowInt(n) {
+			err = fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, n, v.Type())
+		}
+		if err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n int64
+		if n, err = convertE[int64](val); err == nil && (n < 0 || v.OverflowUint(uint64(n))) {
+			err = fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, n, v.Type())
+		}
+		if err == nil {
+			v.SetUint(uint64(n))
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = convertE[float64](val)
+		v.SetFloat(f)
+	default:
+		return mismatch(path, val, v)
+	}
+	if err != nil {
+		return &KeyError{Key: path, Err: err}
+	}
+	return nil
+}
+
+// decodeStruct decodes the object m at path into the struct v.
+func (d *decoder) decodeStruct(path string, m map[string]any, v reflect.Value) error {
+	t := v.Type()
+	used := make(map[string]bool, len(m))
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := fieldTag(field); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		val, ok := m[name]
+		if !ok {
+			for key, elem := range m {
+				if strings.EqualFold(key, name) {
+					val, ok, name = elem, true, key
+					break
+				}
+			}
+		}
+		if !ok {
+			def, hasDef, required := fieldOptions(field)
+			switch {
+			case hasDef:
+				val = def
+			case required:
+				d.missing = append(d.missing, joinPath(path, name))
+				continue
+			case field.Type.Kind() == reflect.Struct:
+				// apply the defaults and check the required keys of the fields of the struct
+				val = map[string]any{}
+			default:
+				continue
+			}
+		}
+		used[name] = true
+		if err := d.decodeValue(joinPath(path, name), val, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	if d.strict {
+		for _, key := range sortedKeys(m) {
+			if !used[key] {
+				return &KeyError{Key: joinPath(path, key), Err: ErrUnknownKey}
+			}
+		}
+	}
+	return nil
+}
+
+// fieldTag returns the name given to field by its "config" tag, or else its "json" tag, if any.
+// Tags without a name, such as `json:",omitempty"`, are ignored.
+func fieldTag(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"config", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			if name, _, _ := strings.Cut(tag, ","); name != "" {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// fieldOptions returns the default value and whether the field is required as given by the
+// options of its "config" tag, such as `config:"port,default=8080,required"`.
+func fieldOptions(field reflect.StructField) (def string, hasDef, required bool) {
+	tag, ok := field.Tag.Lookup("config")
+	if !ok {
+		return "", false, false
+	}
+	_, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch {
+		case opt == "required":
+			required = true
+		case strings.HasPrefix(opt, "default="):
+			def, hasDef = strings.TrimPrefix(opt, "default="), true
+		}
+	}
+	return def, hasDef, required
+}
+
+// joinPath returns the path of key nested in the object at path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isComposite reports whether val is an object or an array.
+func isComposite(val any) bool {
+	switch val.(type) {
+	case map[string]any, []any:
+		return true
+	default:
+		return false
+	}
+}
+
+// mismatch returns the error for a value at path that cannot be decoded into v.
+func mismatch(path string, val any, v reflect.Value) error {
+	return &KeyError{Key: path, Err: fmt.Errorf("%w: cannot decode %s into %s", ErrTypeMismatch, typeName(val), v.Type())}
+}
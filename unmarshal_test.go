@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	Host    string        `config:"host"`
+	Port    uint16        `json:"port"`
+	Timeout time.Duration `config:"timeout"`
+	Addr    net.IP        `config:"addr"`
+	TLS     *struct {
+		Cert string
+	} `config:"tls"`
+}
+
+type testApp struct {
+	Name     string
+	Debug    bool
+	Ratio    float32
+	Tags     []string
+	Pair     [2]int
+	Limits   map[string]int
+	Server   testServer `config:"server"`
+	Skipped  string     `config:"-"`
+	internal string
+}
+
+func TestUnmarshal(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{
+		"NAME": "app", "debug": "true", "ratio": "0.5", "tags": ["a", 1], "pair": [1, "2"],
+		"limits": {"conns": "10"}, "skipped": "x", "internal": "x",
+		"server": {"host": "localhost", "port": "8080", "timeout": "1m30s", "addr": "10.0.0.1", "tls": {"cert": "c.pem"}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("server.port", 80)
+
+	var got testApp
+	if err := c.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := testApp{
+		Name:   "app",
+		Debug:  true,
+		Ratio:  0.5,
+		Tags:   []string{"a", "1.000000"},
+		Pair:   [2]int{1, 2},
+		Limits: map[string]int{"conns": 10},
+		Server: testServer{
+			Host:    "localhost",
+			Port:    8080,
+			Timeout: 90 * time.Second,
+			Addr:    net.ParseIP("10.0.0.1"),
+			TLS:     &struct{ Cert string }{"c.pem"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	c.Delete("server.port")
+	if err := c.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Server.Port != 80 {
+		t.Errorf("got port %d, want the default", got.Server.Port)
+	}
+
+	var m map[string]any
+	if err := c.Unmarshal(&m); err != nil || m["NAME"] != "app" {
+		t.Errorf("got %v, %v decoding into a map", m, err)
+	}
+	c.Set("server.port", 70000)
+	var kerr *KeyError
+	if err := c.Unmarshal(&got); !errors.As(err, &kerr) || !errors.Is(err, ErrOutOfRange) || kerr.Key != "server.port" {
+		t.Errorf("got %v, want a *KeyError for server.port wrapping ErrOutOfRange", err)
+	}
+	if err := c.Unmarshal(got); err == nil {
+		t.Error("decoded into a non-pointer")
+	}
+}
+
+func TestUnmarshalKey(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"services": {"db": {"host": "db.local", "timeout": "5s"}, "list": [{"host": "a"}]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("services.db.port", 5432)
+	c.SetDefault("cache.host", "cache.local")
+
+	var db testServer
+	if err := c.UnmarshalKey("services.db", &db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Host != "db.local" || db.Port != 5432 || db.Timeout != 5*time.Second {
+		t.Errorf("got %+v, want the section with the defaults nested in it", db)
+	}
+	var cache testServer
+	if err := c.UnmarshalKey("cache", &cache); err != nil || cache.Host != "cache.local" {
+		t.Errorf("got %+v, %v, want the section of defaults only", cache, err)
+	}
+	var first testServer
+	if err := c.UnmarshalKey("services.list[0]", &first); err != nil || first.Host != "a" {
+		t.Errorf("got %+v, %v decoding an array element", first, err)
+	}
+
+	var kerr *KeyError
+	if err := c.UnmarshalKey("missing", &db); !errors.Is(err, ErrKeyNotFound) || !errors.As(err, &kerr) || kerr.Key != "missing" {
+		t.Errorf("got %v, want a *KeyError wrapping ErrKeyNotFound", err)
+	}
+	c.Set("services.db.port", "http")
+	if err := c.UnmarshalKey("services.db", &db); !errors.As(err, &kerr) || kerr.Key != "services.db.port" {
+		t.Errorf("got %v, want the error to name the full key", err)
+	}
+}
+
+func TestDisallowUnknownKeys(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"name": "app", "server": {"host": "a", "hots": "typo"}, "limits": {"any": 1}}`)); err != nil {
+		t.Fatal(err)
+	}
+	var got testApp
+	if err := c.Unmarshal(&got); err != nil {
+		t.Errorf("got %v, want unknown keys ignored by default", err)
+	}
+	var kerr *KeyError
+	if err := c.Unmarshal(&got, DisallowUnknownKeys()); !errors.Is(err, ErrUnknownKey) || !errors.As(err, &kerr) || kerr.Key != "server.hots" {
+		t.Errorf("got %v, want a *KeyError for server.hots wrapping ErrUnknownKey", err)
+	}
+	if err := c.UnmarshalKey("server", &got.Server, DisallowUnknownKeys()); !errors.As(err, &kerr) || kerr.Key != "server.hots" {
+		t.Errorf("UnmarshalKey: got %v", err)
+	}
+
+	c.Delete("server.hots")
+	if err := c.Unmarshal(&got, DisallowUnknownKeys()); err != nil {
+		t.Errorf("got %v, want keys of maps and case-insensitive matches accepted", err)
+	}
+	c.Set("skipped", "x")
+	if err := c.Unmarshal(&got, DisallowUnknownKeys()); !errors.As(err, &kerr) || kerr.Key != "skipped" {
+		t.Errorf("got %v, want the key of a field tagged - unknown", err)
+	}
+}
+
+func TestTagDefaultsAndRequired(t *testing.T) {
+	type db struct {
+		Host    string        `config:"host,required"`
+		Port    int           `config:"port,default=5432"`
+		Timeout time.Duration `config:"timeout,default=5s"`
+	}
+	type app struct {
+		Name string `config:"name,required"`
+		DB   db     `config:"db"`
+		Mode string `config:"mode,default=prod"`
+	}
+
+	c := New()
+	if err := c.LoadBytes([]byte(`{"db": {"port": 6543}}`)); err != nil {
+		t.Fatal(err)
+	}
+	var got app
+	var verr *ValidationError
+	if err := c.Unmarshal(&got); !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	var missing []string
+	for _, f := range verr.Findings {
+		missing = append(missing, f.Key)
+	}
+	if want := []string{"name", "db.host"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("got missing keys %v, want %v", missing, want)
+	}
+
+	c.Set("name", "app")
+	c.Set("db.host", "db.local")
+	if err := c.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := app{Name: "app", DB: db{Host: "db.local", Port: 6543, Timeout: 5 * time.Second}, Mode: "prod"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// defaults of structs without a key of their own are applied as well
+	var d struct {
+		DB db `config:"other"`
+	}
+	if err := c.Unmarshal(&d); !errors.As(err, &verr) || len(verr.Findings) != 1 || verr.Findings[0].Key != "other.host" {
+		t.Errorf("got %v, want other.host missing", err)
+	}
+	if d.DB.Port != 5432 {
+		t.Errorf("got port %d, want the tag default", d.DB.Port)
+	}
+
+	type bad struct {
+		Port int `config:"port,default=http"`
+	}
+	var kerr *KeyError
+	if err := c.Unmarshal(&bad{}); !errors.Is(err, ErrParse) || !errors.As(err, &kerr) || kerr.Key != "port" {
+		t.Errorf("got %v, want a *KeyError for port wrapping ErrParse", err)
+	}
+}
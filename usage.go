@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// UsageOption configures the output of Usage.
+type UsageOption func(*usageOptions)
+
+// usageOptions holds the settings applied by UsageOption values.
+type usageOptions struct {
+	undeclared bool
+}
+
+// WithUndeclared makes Usage list the keys that are set in the configuration without being
+// declared in a separate section at the end.
+func WithUndeclared() UsageOption {
+	return func(o *usageOptions) {
+		o.undeclared = true
+	}
+}
+
+// usageWidth is the width Usage wraps descriptions at.
+const usageWidth = 80
+
+// Usage writes help text for the configuration to w in a format similar to
+// flag.PrintDefaults: every key with a default or a description or declared with Require is
+// listed with its type and a marker for required keys, followed by its description, wrapped and
+// indented, its default and the environment variables overriding it, as bound with BindEnv or
+// mapped by AutomaticEnv. Keys are sorted and grouped by their first segment. Values that are set
+// without being declared are omitted unless WithUndeclared is given, so the output reflects the
+// supported settings rather than the loaded ones.
+func (c *Configuration) Usage(w io.Writer, opts ...UsageOption) error {
+	var o usageOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c.mu.RLock()
+	delim := c.delimiter()
+	declared := c.declaredKeys()
+	keys := sortedKeys(declared)
+	// keys without a group come first, followed by the groups
+	sort.SliceStable(keys, func(i, j int) bool {
+		return !strings.Contains(keys[i], delim) && strings.Contains(keys[j], delim)
+	})
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		u := usage{key: key, desc: c.descs[key], required: c.required[key], env: c.envVars(key)}
+		u.def, u.hasDefault = c.defaults[key]
+		val := u.def
+		if !u.hasDefault {
+			val, _ = findPath(c.keyvals, key, delim)
+			val = resolve(val)
+		}
+		u.typ = typeName(val)
+		entries[i] = u.String()
+	}
+	var undeclared []string
+	if o.undeclared {
+		undeclared = c.undeclaredKeys(declared)
+	}
+	c.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	group := ""
+	for i, key := range keys {
+		if g, _, ok := strings.Cut(key, delim); ok && g != group {
+			group = g
+			fmt.Fprintf(bw, "%s:\n", g)
+		}
+		fmt.Fprint(bw, entries[i])
+	}
+	if len(undeclared) > 0 {
+		fmt.Fprintln(bw, "undeclared keys:")
+		for _, key := range undeclared {
+			fmt.Fprintf(bw, "  %s\n", key)
+		}
+	}
+	return bw.Flush()
+}
+
+// usage describes a key listed by Usage.
+type usage struct {
+	key, typ, desc string
+	def            any
+	hasDefault     bool
+	required       bool
+	env            []string // variables overriding the key in order of priority
+}
+
+// String renders the help text of the key.
+func (u usage) String() string {
+	var b strings.Builder
+	b.WriteString("  " + u.key)
+	if u.typ != "" {
+		b.WriteString(" " + u.typ)
+	}
+	if u.required {
+		b.WriteString(" (required)")
+	}
+	b.WriteString("\n")
+	desc := u.desc
+	if u.hasDefault {
+		desc += " (default " + usageValue(u.def) + ")"
+	}
+	if len(u.env) > 0 {
+		desc += " (env " + strings.Join(u.env, ", ") + ")"
+	}
+	desc = strings.TrimSpace(desc)
+	line := ""
+	for _, word := range strings.Fields(desc) {
+		if line != "" && 6+len(line)+1+len(word) > usageWidth {
+			b.WriteString("    \t" + line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		b.WriteString("    \t" + line + "\n")
+	}
+	return b.String()
+}
+
+// usageValue renders a default value for Usage.
+func usageValue(val any) string {
+	if s, ok := val.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(data)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"server": {"port": 8080, "debug": true}, "name": "demo"}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	c.Describe("server.port", "port to listen on")
+	c.SetDefault("server.host", "localhost")
+	c.SetDefault("log_level", "info")
+	c.Require("db.url")
+	if err := c.AutomaticEnv("MYAPP"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BindEnv("db.url", "DATABASE_URL"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Usage(&buf, WithUndeclared()); err != nil {
+		t.Fatal(err)
+	}
+	want := `  log_level string
+    	(default "info")
+db:
+  db.url (required)
+    	(env DATABASE_URL, MYAPP_DB_URL)
+server:
+  server.host string
+    	(default "localhost") (env MYAPP_SERVER_HOST)
+  server.port number
+    	port to listen on (env MYAPP_SERVER_PORT)
+undeclared keys:
+  name
+  server.debug
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+)
+
+// Severity classifies a validation finding.
+type Severity string
+
+const (
+	// SeverityError marks findings that make a configuration unusable.
+	SeverityError Severity = "error"
+	// SeverityWarning marks findings that are likely mistakes, such as unknown keys.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a single problem found while validating a configuration document.
+type Finding struct {
+	Key      string
+	Severity Severity
+	Message  string
+}
+
+// String formats the finding as "severity: key: message".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Key, f.Message)
+}
+
+// ValidationError aggregates the findings of ValidateFile and ValidateBytes.
+type ValidationError struct {
+	Findings []Finding
+}
+
+// Error lists all findings, one per line.
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		lines[i] = f.String()
+	}
+	return "config: invalid configuration:\n" + strings.Join(lines, "\n")
+}
+
+// ValidateFile checks the configuration file fname against the keys declared in c without
+// applying it. See ValidateBytes.
+func (c *Configuration) ValidateFile(fname string) error {
+	data, err := readFile(fname)
+	if err != nil {
+		return err
+	}
+	return c.validate(formatOf(fname).decode, data)
+}
+
+// ValidateBytes checks the JSON configuration document data against the keys declared in c
+// without applying it. Values whose type differs from the type of the default of their key are
+// reported as errors and keys that are neither declared nor lead to declared nested keys as
+// warnings. Parse errors are returned as is, all findings together as a *ValidationError.
+func (c *Configuration) ValidateBytes(data []byte) error {
+	return c.validate(decodeJSON, data)
+}
+
+// validate implements ValidateBytes for documents decoded by decode.
+func (c *Configuration) validate(decode func([]byte) (map[string]any, error), data []byte) error {
+	keyvals, err := decode(data)
+	if err == nil {
+		keyvals, err = applyConditions(keyvals)
+	}
+	if err != nil {
+		return err
+	}
+
+	if findings := c.findings(keyvals); len(findings) > 0 {
+		return &ValidationError{Findings: findings}
+	}
+	return nil
+}
+
+// findings validates the decoded document keyvals against the keys declared in c.
+func (c *Configuration) findings(keyvals map[string]any) []Finding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.findingsLocked(keyvals)
+}
+
+// findingsWith is like findings but also treats the keys of defaults as declared with the given
+// defaults, without registering them in c.
+func (c *Configuration) findingsWith(keyvals, defaults map[string]any) []Finding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v := validator{declared: c.declaredKeys(), defaults: maps.Clone(c.defaults)}
+	if v.defaults == nil {
+		v.defaults = make(map[string]any, len(defaults))
+	}
+	for key, val := range defaults {
+		v.declared[key] = true
+		v.defaults[key] = val
+	}
+	v.validate("", keyvals)
+	return v.findings
+}
+
+// findingsLocked implements findings. The caller must hold c.mu.
+func (c *Configuration) findingsLocked(keyvals map[string]any) []Finding {
+	v := validator{declared: c.declaredKeys(), defaults: c.defaults}
+	v.validate("", keyvals)
+	return v.findings
+}
+
+// validator collects findings for a decoded configuration document.
+type validator struct {
+	declared map[string]bool
+	defaults map[string]any
+	findings []Finding
+}
+
+// validate checks the keys of m, which are nested below prefix.
+func (v *validator) validate(prefix string, m map[string]any) {
+	for _, k := range sortedKeys(m) {
+		key := prefix + k
+		val := m[k]
+		switch {
+		case v.declared[key]:
+			v.checkType(key, val)
+		case v.hasNested(key):
+			if sub, ok := val.(map[string]any); ok {
+				v.validate(key+".", sub)
+			} else {
+				v.add(key, SeverityError, fmt.Sprintf("expected object, got %s", typeName(val)))
+			}
+		default:
+			v.add(key, SeverityWarning, "unknown key")
+		}
+	}
+}
+
+// checkType reports val if its type does not match the type of the default of key.
+func (v *validator) checkType(key string, val any) {
+	def, ok := v.defaults[key]
+	if !ok || def == nil || val == nil {
+		return
+	}
+	want, got := typeName(def), typeName(val)
+	switch {
+	case want == got:
+	case want == "number" && got == "integer":
+	case want == "integer" && got == "number" && val.(float64) == math.Trunc(val.(float64)):
+	default:
+		v.add(key, SeverityError, fmt.Sprintf("expected %s, got %s", want, got))
+	}
+}
+
+// hasNested reports whether a key nested below key is declared.
+func (v *validator) hasNested(key string) bool {
+	for k := range v.declared {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a finding.
+func (v *validator) add(key string, sev Severity, msg string) {
+	v.findings = append(v.findings, Finding{Key: key, Severity: sev, Message: msg})
+}
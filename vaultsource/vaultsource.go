@@ -0,0 +1,165 @@
+// Package vaultsource provides a configuration provider reading secrets from a KV version 2
+// secrets engine of HashiCorp Vault into designated configuration keys:
+//
+//	src := vaultsource.New(client, "secret", map[string]string{
+//		"db.credentials": "myapp/db",
+//		"api":            "myapp/api",
+//	})
+//	err := config.Config().StartWatch(ctx, src)
+//
+// Each secret is stored as an object at its dot separated key, so the field "password" of the
+// secret "myapp/db" above becomes "db.credentials.password". While watching, the secrets are
+// read again every RefreshInterval, or before their lease expires if that is earlier, and
+// rotated secrets are reported as a new document. A renewable client token is renewed for as
+// long as Vault allows.
+package vaultsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// RefreshInterval is the interval at which watched secrets are read again to detect rotations.
+var RefreshInterval = time.Minute
+
+// Source is a config.Provider reading secrets from a KV version 2 secrets engine.
+type Source struct {
+	client  *api.Client
+	mount   string
+	secrets map[string]string // secret paths by configuration key
+
+	mu       sync.Mutex
+	versions map[string]int // secret versions by path, as of the last read
+	lease    time.Duration  // shortest lease of the secrets of the last read, 0 if none
+}
+
+// New returns a Source reading the secrets of the KV version 2 engine mounted at mount using
+// client. secrets maps dot separated configuration keys to the paths of the secrets stored at
+// them.
+func New(client *api.Client, mount string, secrets map[string]string) *Source {
+	return &Source{client: client, mount: mount, secrets: secrets}
+}
+
+// Name identifies the source by its mount path.
+func (s *Source) Name() string {
+	return "vault:" + s.mount
+}
+
+// Load reads the current versions of the secrets.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	doc, _, err := s.read(ctx)
+	return doc, err
+}
+
+// Watch reads the secrets again periodically and reports the document whenever a secret was
+// rotated, while keeping the client token renewed. It fails when the token can no longer be
+// renewed, so the token must be replaced before watching is restarted.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	var renewed <-chan *api.RenewOutput
+	var expired <-chan error
+	if w, err := s.renewToken(ctx); err != nil {
+		return err
+	} else if w != nil {
+		go w.Start()
+		defer w.Stop()
+		renewed, expired = w.RenewCh(), w.DoneCh()
+	}
+
+	t := time.NewTimer(s.next())
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-renewed:
+		case err := <-expired:
+			if err == nil {
+				err = errors.New("token reached its maximum TTL")
+			}
+			return fmt.Errorf("renewing vault token: %w", err)
+		case <-t.C:
+			doc, rotated, err := s.read(ctx)
+			if err != nil {
+				return err
+			}
+			if rotated {
+				fn(doc)
+			}
+			t.Reset(s.next())
+		}
+	}
+}
+
+// renewToken returns a watcher renewing the client token, or nil if the token is not renewable.
+func (s *Source) renewToken(ctx context.Context) (*api.LifetimeWatcher, error) {
+	self, err := s.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("looking up vault token: %w", err)
+	}
+	if ok, err := self.TokenIsRenewable(); err != nil || !ok {
+		return nil, err
+	}
+	// the lifetime watcher needs the auth information only returned by a renewal
+	secret, err := s.client.Auth().Token().RenewSelfWithContext(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("renewing vault token: %w", err)
+	}
+	return s.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+}
+
+// next returns the delay before the secrets are read again: RefreshInterval, or two thirds of
+// the shortest lease if that is earlier.
+func (s *Source) next() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lease > 0 && s.lease*2/3 < RefreshInterval {
+		return s.lease * 2 / 3
+	}
+	return RefreshInterval
+}
+
+// read reads all secrets and reports whether any version differs from the last read.
+func (s *Source) read(ctx context.Context) (map[string]any, bool, error) {
+	kv := s.client.KVv2(s.mount)
+	flat := make(map[string]any, len(s.secrets))
+	versions := make(map[string]int, len(s.secrets))
+	var lease time.Duration
+	for key, path := range s.secrets {
+		secret, err := kv.Get(ctx, path)
+		if err != nil {
+			return nil, false, err
+		}
+		flat[key] = secret.Data
+		if secret.VersionMetadata != nil {
+			versions[path] = secret.VersionMetadata.Version
+		}
+		if secret.Raw != nil && secret.Raw.LeaseDuration > 0 {
+			d := time.Duration(secret.Raw.LeaseDuration) * time.Second
+			if lease == 0 || d < lease {
+				lease = d
+			}
+		}
+	}
+	doc, err := config.Nest(flat, ".")
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	rotated := len(versions) != len(s.versions)
+	for path, v := range versions {
+		if s.versions[path] != v {
+			rotated = true
+		}
+	}
+	s.versions, s.lease = versions, lease
+	s.mu.Unlock()
+	return doc, rotated, nil
+}
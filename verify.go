@@ -0,0 +1,80 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a configuration file does not match its expected checksum.
+var ErrChecksumMismatch = errors.New("config: checksum mismatch")
+
+// ErrInvalidSignature is returned when a configuration file does not match its signature.
+var ErrInvalidSignature = errors.New("config: invalid signature")
+
+// ReadFileVerified is like Configuration.ReadFileVerified for the global configuration.
+func ReadFileVerified(fname string, sha256hex string) error {
+	return config.ReadFileVerified(fname, sha256hex)
+}
+
+// ReadFileVerified reads a configuration file and updates c if the SHA-256 of the file content
+// matches the hex encoded sha256hex. The file is read once and the verified bytes are decoded, so
+// the content cannot change between verification and parsing. Reload and WatchFile verify the
+// file again and fail to reload content that does not match; files included with "$include" are
+// not verified.
+func (c *Configuration) ReadFileVerified(fname string, sha256hex string) error {
+	if err := checkChecksum(sha256hex); err != nil {
+		return err
+	}
+	return c.readVerified(fileSource{name: fname, checksum: strings.ToLower(sha256hex)})
+}
+
+// ReadFileSigned is like Configuration.ReadFileSigned for the global configuration.
+func ReadFileSigned(fname string, sig []byte, pub ed25519.PublicKey) error {
+	return config.ReadFileSigned(fname, sig, pub)
+}
+
+// ReadFileSigned reads a configuration file and updates c if sig is a valid ed25519 signature of
+// the file content by pub. Like ReadFileVerified it decodes exactly the bytes that were verified
+// and makes Reload and WatchFile verify the file again.
+func (c *Configuration) ReadFileSigned(fname string, sig []byte, pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("config: invalid ed25519 public key length %d", len(pub))
+	}
+	return c.readVerified(fileSource{name: fname, sig: slices.Clone(sig), pub: slices.Clone(pub)})
+}
+
+// readVerified reads the file f, which holds the checksum or signature it must match, and merges
+// it into the configuration.
+func (c *Configuration) readVerified(f fileSource) error {
+	files := []fileSource{f}
+	keyvals, hash, err := c.readFiles(files)
+	if err != nil {
+		return err
+	}
+	return c.merge(keyvals, files, hash)
+}
+
+// checkChecksum fails if sha256hex is not a hex encoded SHA-256 checksum.
+func checkChecksum(sha256hex string) error {
+	if _, err := hex.DecodeString(sha256hex); err != nil || len(sha256hex) != 64 {
+		return fmt.Errorf("config: invalid SHA-256 checksum %q", sha256hex)
+	}
+	return nil
+}
+
+// verify fails if data, the content of f, does not match the checksum or signature of f.
+func (f fileSource) verify(data []byte) error {
+	if f.checksum != "" {
+		if actual := hashOf(data); actual != f.checksum {
+			return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, f.name, f.checksum, actual)
+		}
+	}
+	if f.pub != nil && !ed25519.Verify(f.pub, data, f.sig) {
+		return fmt.Errorf("%w for %s", ErrInvalidSignature, f.name)
+	}
+	return nil
+}
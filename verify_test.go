@@ -0,0 +1,84 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReadFileVerifiedReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "extra.json", `{"extra": true}`)
+	content := `{"v": 1, "$include": "extra.json"}`
+	fname := writeFile(t, dir, "app.json", content)
+
+	c := New()
+	if err := c.ReadFileVerified(fname, hashOf([]byte(content))); err != nil {
+		t.Fatal(err)
+	}
+	if !c.GetBool("extra") {
+		t.Fatal("$include was not applied")
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fname, []byte(`{"v": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got error %v reloading tampered file, want ErrChecksumMismatch", err)
+	}
+	if got := c.GetInt("v"); got != 1 {
+		t.Fatalf("tampered file was applied, v is %d", got)
+	}
+}
+
+func TestReadFileSignedReload(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(`{"v": 1}`)
+	fname := writeFile(t, t.TempDir(), "app.json", string(content))
+
+	c := New()
+	if err := c.ReadFileSigned(fname, ed25519.Sign(priv, []byte(`{"v": 2}`)), pub); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("got error %v for a wrong signature, want ErrInvalidSignature", err)
+	}
+	if err := c.ReadFileSigned(fname, ed25519.Sign(priv, content), pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fname, []byte(`{"v": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("got error %v reloading tampered file, want ErrInvalidSignature", err)
+	}
+	if got := c.GetInt("v"); got != 1 {
+		t.Fatalf("tampered file was applied, v is %d", got)
+	}
+}
+
+func TestLoadWithChecksum(t *testing.T) {
+	content := `{"v": 1}`
+	fname := writeFile(t, t.TempDir(), "app.json", content)
+
+	c := New()
+	if err := c.Load(fname, WithChecksum(hashOf([]byte("other")))); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got error %v, want ErrChecksumMismatch", err)
+	}
+	if c.Exists("v") {
+		t.Fatal("file failing verification was applied")
+	}
+	if err := c.Load(fname, WithChecksum("abc")); err == nil {
+		t.Fatal("malformed checksum accepted")
+	}
+	if err := c.Load(fname, WithChecksum(hashOf([]byte(content)))); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetInt("v"); got != 1 {
+		t.Fatalf("got v %d, want 1", got)
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// volumeDataDir is the symlink through which Kubernetes projects the files of ConfigMap and
+// Secret volumes. Updates write the new files to a fresh directory and atomically swap the
+// symlink to it.
+const volumeDataDir = "..data"
+
+// VolumeSource is a Provider reading the files of a directory into which Kubernetes projects a
+// ConfigMap or Secret, such as /etc/myapp. Files whose extension has a registered format are
+// decoded and merged in lexical order of their names, as ReadFiles does; every other file sets
+// the key named like the file to its content without a trailing newline. Hidden files and
+// subdirectories are skipped.
+//
+// The files are read through the "..data" symlink of the volume, so a document never mixes
+// files from before and after an update, and watching the source detects the symlink swaps
+// Kubernetes performs. Directories without the symlink are read and watched directly. Pass the
+// source to StartWatch to keep the configuration updated with the volume.
+type VolumeSource struct {
+	Dir string
+}
+
+// NewVolumeSource returns a VolumeSource reading the files of dir.
+func NewVolumeSource(dir string) *VolumeSource {
+	return &VolumeSource{Dir: dir}
+}
+
+// Name returns the directory.
+func (s *VolumeSource) Name() string {
+	return s.Dir
+}
+
+// Load reads the files of the volume.
+func (s *VolumeSource) Load(ctx context.Context) (map[string]any, error) {
+	dir := s.Dir
+	if target, err := filepath.EvalSymlinks(filepath.Join(s.Dir, volumeDataDir)); err == nil {
+		dir = target
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any)
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		data, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !registered(filepath.Ext(name)) {
+			doc[name] = fileValue(data)
+			continue
+		}
+		sub, err := formatOf(name).decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+		deepMerge(doc, sub)
+	}
+	return doc, nil
+}
+
+// Watch reports the document whenever the files of the volume changed, until ctx is done.
+func (s *VolumeSource) Watch(ctx context.Context, fn func(map[string]any)) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+	if err := fsw.Add(s.Dir); err != nil {
+		return err
+	}
+	// the volume may have changed before the watch was established
+	prev, err := s.Load(ctx)
+	if err != nil {
+		return err
+	}
+	fn(deepCopyMap(prev))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			base := filepath.Base(ev.Name)
+			// the temporary symlink and timestamped directories of an update are only
+			// visible once "..data" points to them
+			if strings.HasPrefix(base, ".") && base != volumeDataDir {
+				continue
+			}
+			doc, err := s.Load(ctx)
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(doc, prev) {
+				prev = doc
+				fn(deepCopyMap(doc))
+			}
+		}
+	}
+}
+
+// fileValue returns the content of a file holding a single value without a trailing newline.
+func fileValue(data []byte) string {
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+}
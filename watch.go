@@ -0,0 +1,176 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reloads a configuration whenever one of the files it was read from changes.
+type Watcher struct {
+	c        *Configuration
+	files    []watchedFile
+	lastHash string // hash of the content loaded at the last reload
+	fsw      *fsnotify.Watcher
+	done     chan struct{}
+	stop     context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// watchedFile is a file watched by a Watcher.
+type watchedFile struct {
+	name     string // cleaned file name
+	realPath string // name with symlinks resolved at the last reload
+	optional bool
+}
+
+// WatchFile watches the configuration file fname and reloads c, as Reload does, whenever the
+// file or any other file c was read from changes; fname is added to these files if c was not read
+// from it. The parent directories are watched rather than the files, so editors replacing a file
+// by a rename and Kubernetes ConfigMap volumes swapping the "..data" symlink are both detected,
+// as are optional files such as local override files appearing and disappearing. Every change of
+// the file contents is applied exactly once; a failed reload leaves the configuration unchanged
+// and is reported by Err.
+func (c *Configuration) WatchFile(fname string) (*Watcher, error) {
+	return c.WatchFileCtx(context.Background(), fname)
+}
+
+// WatchFileCtx is like WatchFile but also stops watching when ctx is done.
+func (c *Configuration) WatchFileCtx(ctx context.Context, fname string) (*Watcher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("config: watching %s: %w", fname, err)
+	}
+	if _, err := filepath.EvalSymlinks(fname); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	found := false
+	for _, f := range c.files {
+		found = found || filepath.Clean(f.name) == filepath.Clean(fname)
+	}
+	if !found {
+		c.addFiles([]fileSource{{name: fname, present: true}})
+	}
+	var files []watchedFile
+	for _, f := range c.files {
+		if f.fsys != nil {
+			continue
+		}
+		realPath, _ := filepath.EvalSymlinks(f.name)
+		files = append(files, watchedFile{name: filepath.Clean(f.name), realPath: realPath, optional: f.optional})
+	}
+	c.mu.Unlock()
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Dir(f.name)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return nil, err
+		}
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	w := &Watcher{
+		c:     c,
+		files: files,
+		fsw:   fsw,
+		done:  make(chan struct{}),
+		stop:  stop,
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// Close stops watching the files and waits until a reload in progress finished.
+func (w *Watcher) Close() error {
+	w.stop()
+	<-w.done
+	return nil
+}
+
+// Err returns the error of the last reload or watch failure, or nil if the last reload succeeded.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// run processes file system events until ctx is done.
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.fsw.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if w.changed(ev) {
+				w.setErr(w.reload())
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.setErr(err)
+		}
+	}
+}
+
+// changed reports whether ev may have changed the content of a watched file, either by writing,
+// replacing or, for optional files, removing the file itself or by changing the target of a
+// symlink on its path.
+func (w *Watcher) changed(ev fsnotify.Event) bool {
+	for _, f := range w.files {
+		if filepath.Clean(ev.Name) == f.name {
+			if ev.Has(fsnotify.Write | fsnotify.Create) {
+				return true
+			}
+			if f.optional && ev.Has(fsnotify.Remove|fsnotify.Rename) {
+				return true
+			}
+		}
+		if realPath, err := filepath.EvalSymlinks(f.name); err == nil && realPath != f.realPath {
+			return true
+		}
+	}
+	return false
+}
+
+// reload reloads the configuration if the content of the watched files differs from the content
+// loaded last.
+func (w *Watcher) reload() error {
+	for i := range w.files {
+		w.files[i].realPath, _ = filepath.EvalSymlinks(w.files[i].name)
+	}
+	hash, err := w.c.reloadFiles(w.lastHash)
+	if err != nil {
+		return err
+	}
+	w.lastHash = hash
+	return nil
+}
+
+// setErr records the outcome of the last reload.
+func (w *Watcher) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
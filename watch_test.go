@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventually fails the test if cond does not become true within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeFile writes content to the file name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// swapData points the "..data" symlink in dir to the new directory version holding app.json with
+// content, the way the kubelet updates ConfigMap volumes.
+func swapData(t *testing.T, dir, version, content string) {
+	t.Helper()
+	writeFile(t, dir, filepath.Join(version, "app.json"), content)
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(version, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchFileConfigMapSwap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	dir := t.TempDir()
+	writeFile(t, dir, "..2026_01/app.json", `{"v": 1}`)
+	if err := os.Symlink("..2026_01", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	fname := filepath.Join(dir, "app.json")
+	if err := os.Symlink(filepath.Join("..data", "app.json"), fname); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	var reloads atomic.Int32
+	c.OnReload(func([]Change) { reloads.Add(1) })
+	w, err := c.WatchFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	swapData(t, dir, "..2026_02", `{"v": 2}`)
+	eventually(t, "the reload", func() bool { return c.GetInt("v") == 2 })
+	time.Sleep(200 * time.Millisecond)
+	if n := reloads.Load(); n != 1 {
+		t.Fatalf("got %d reloads for one swap, want 1", n)
+	}
+
+	attempts := c.Metrics().ReloadAttempts
+	swapData(t, dir, "..2026_03", `{"v": 2}`)
+	eventually(t, "the reload attempt", func() bool { return c.Metrics().ReloadAttempts > attempts })
+	time.Sleep(200 * time.Millisecond)
+	if n := reloads.Load(); n != 1 {
+		t.Fatalf("got %d reloads after swapping in unchanged content, want 1", n)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchFileCtxCancel(t *testing.T) {
+	fname := writeFile(t, t.TempDir(), "app.json", `{"v": 1}`)
+	c := New()
+	if err := c.ReadFile(fname); err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := c.WatchFileCtx(ctx, fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-w.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop after the context was canceled")
+	}
+	eventually(t, "the watcher goroutines to exit", func() bool { return runtime.NumGoroutine() <= before })
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
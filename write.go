@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteOption configures how WriteFile persists the configuration.
+type WriteOption func(*writeOptions)
+
+// writeOptions holds the settings applied by WriteOption values.
+type writeOptions struct {
+	backups int
+	lock    bool
+	nested  bool
+}
+
+// newWriteOptions applies opts to the default write settings.
+func newWriteOptions(opts []WriteOption) writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithBackup keeps up to n previous versions of the file when WriteFile replaces it. The most
+// recent version is kept as <fname>.bak, older ones as <fname>.bak.1 up to <fname>.bak.<n-1>.
+// No backup is made when the file content is unchanged.
+func WithBackup(n int) WriteOption {
+	return func(o *writeOptions) {
+		o.backups = n
+	}
+}
+
+// WithNested makes WriteFile nest dot separated keys into objects, see Nest, inverting the
+// flattening of WithFlatten. Writing fails if a key is also the prefix of other keys.
+func WithNested() WriteOption {
+	return func(o *writeOptions) {
+		o.nested = true
+	}
+}
+
+// WriteFile writes the configuration to fname in the format registered for its extension,
+// indented JSON by default, so changes made at runtime survive restarts. It writes the loaded
+// values together with the values set with Set, but not those of environment variables and
+// flags. The file is replaced atomically so readers see either the previous or the new content,
+// and the permissions of an existing file are preserved. Writing a file whose content already
+// matches the configuration is a no-op.
+func (c *Configuration) WriteFile(fname string, opts ...WriteOption) error {
+	o := newWriteOptions(opts)
+	if o.lock {
+		unlock, err := lockFile(fname, LockTimeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	return c.writeFile(fname, o)
+}
+
+// writeFile implements WriteFile once the file lock, if requested, is held.
+func (c *Configuration) writeFile(fname string, o writeOptions) error {
+	c.mu.RLock()
+	keyvals := resolveAll(c.persisted())
+	c.mu.RUnlock()
+	if o.nested {
+		var err error
+		if keyvals, err = Nest(keyvals, "."); err != nil {
+			return err
+		}
+	}
+	data, err := encodeFile(fname, keyvals)
+	if err != nil {
+		return err
+	}
+
+	perm := fs.FileMode(0o644)
+	old, err := os.ReadFile(fname)
+	switch {
+	case err == nil:
+		if bytes.Equal(old, data) {
+			return nil
+		}
+		if fi, err := os.Stat(fname); err == nil {
+			perm = fi.Mode().Perm()
+		}
+		if o.backups > 0 {
+			if err := rotateBackups(fname, o.backups, old, perm); err != nil {
+				return err
+			}
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	return writeFileAtomic(fname, data, perm)
+}
+
+// RestoreBackup swaps the most recent backup made by WriteFile with the current content of
+// fname, so calling it twice returns to the original state.
+func RestoreBackup(fname string) error {
+	bak := backupName(fname, 0)
+	if _, err := os.Stat(bak); err != nil {
+		return fmt.Errorf("config: no backup of %s: %w", fname, err)
+	}
+	cur, err := os.ReadFile(fname)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return os.Rename(bak, fname)
+	}
+	fi, err := os.Stat(fname)
+	if err != nil {
+		return err
+	}
+
+	// keep the current content in a temporary file until the backup took its place
+	tmp, err := writeTemp(fname, cur, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(bak, fname); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, bak)
+}
+
+// backupName returns the name of the i-th most recent backup of fname.
+func backupName(fname string, i int) string {
+	if i == 0 {
+		return fname + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", fname, i)
+}
+
+// rotateBackups shifts the existing backups of fname, dropping the oldest beyond n, and stores
+// data as the most recent backup.
+func rotateBackups(fname string, n int, data []byte, perm fs.FileMode) error {
+	for i := n - 1; i > 0; i-- {
+		err := os.Rename(backupName(fname, i-1), backupName(fname, i))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupName(fname, 0), data, perm)
+}
+
+// writeFileAtomic replaces fname with data by writing a temporary file and renaming it.
+func writeFileAtomic(fname string, data []byte, perm fs.FileMode) error {
+	tmp, err := writeTemp(fname, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes data to a new temporary file next to fname and returns its name.
+func writeTemp(fname string, data []byte, perm fs.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
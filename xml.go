@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlTextKey is the key of the text content of XML elements that also have attributes or child
+// elements.
+const xmlTextKey = "#text"
+
+func init() {
+	RegisterFormat(".xml", decodeXML, nil)
+}
+
+// decodeXML decodes an XML document into a key-value map. The attributes and child elements of
+// the root element become the keys of the configuration, and those of nested elements the keys
+// of nested objects. Elements holding only text become strings, the text of other elements is
+// kept under the key "#text". Elements repeated under the same parent become arrays. Namespace
+// prefixes are dropped. XML documents can only be read.
+func decodeXML(data []byte) (map[string]any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("config: XML document has no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			val, err := decodeXMLElement(d, start, 1)
+			if err != nil {
+				return nil, err
+			}
+			if m, ok := val.(map[string]any); ok {
+				return m, nil
+			}
+			return map[string]any{}, nil
+		}
+	}
+}
+
+// decodeXMLElement decodes the element started by start at nesting depth depth.
+func decodeXMLElement(d *xml.Decoder, start xml.StartElement, depth int) (any, error) {
+	if MaxDepth > 0 && depth > MaxDepth {
+		return nil, fmt.Errorf("%w of %d", ErrTooDeep, MaxDepth)
+	}
+	m := make(map[string]any)
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		m[attr.Name.Local] = attr.Value
+	}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			val, err := decodeXMLElement(d, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch prev := m[name].(type) {
+			case nil:
+				m[name] = val
+			case []any:
+				m[name] = append(prev, val)
+			default:
+				m[name] = []any{prev, val}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return s, nil
+			}
+			if s != "" {
+				m[xmlTextKey] = s
+			}
+			return m, nil
+		}
+	}
+}
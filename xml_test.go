@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      map[string]any
+	}{
+		{"elements", `<config><name>app</name><server><port>80</port></server></config>`, map[string]any{
+			"name": "app", "server": map[string]any{"port": "80"},
+		}},
+		{"attributes", `<config debug="true"><server host="localhost" port="80"/></config>`, map[string]any{
+			"debug": "true", "server": map[string]any{"host": "localhost", "port": "80"},
+		}},
+		{"text next to attributes", `<config><db driver="pg"> postgres://x </db></config>`, map[string]any{
+			"db": map[string]any{"driver": "pg", "#text": "postgres://x"},
+		}},
+		{"repeated elements", `<config><tag>a</tag><tag>b</tag><tag>c</tag></config>`, map[string]any{
+			"tag": []any{"a", "b", "c"},
+		}},
+		{"namespaces", `<c:config xmlns:c="urn:x" xmlns="urn:y"><c:name>app</c:name></c:config>`, map[string]any{
+			"name": "app",
+		}},
+		{"prolog, comments and entities", "<?xml version=\"1.0\"?>\n<!-- comment -->\n<config><q>a &amp; b</q><!-- x --><empty/></config>", map[string]any{
+			"q": "a & b", "empty": "",
+		}},
+		{"text root", `<config>text</config>`, map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeXML([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeXMLErrors(t *testing.T) {
+	for _, doc := range []string{
+		``,
+		`<!-- only a comment -->`,
+		`<config><name>app</config>`,
+		`<config><name>app</name>`,
+		`<config a="1" a="2"`,
+	} {
+		if _, err := decodeXML([]byte(doc)); err == nil {
+			t.Errorf("%q: decoded", doc)
+		}
+	}
+
+	defer func(depth int) { MaxDepth = depth }(MaxDepth)
+	MaxDepth = 3
+	deep := strings.Repeat("<a>", 5) + strings.Repeat("</a>", 5)
+	if _, err := decodeXML([]byte(deep)); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got %v, want ErrTooDeep", err)
+	}
+}
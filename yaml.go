@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterFormat(".yaml", decodeYAML, encodeYAML)
+	RegisterFormat(".yml", decodeYAML, encodeYAML)
+}
+
+// decodeYAML decodes a YAML document into a key-value map. Mapping keys are converted to strings
+// and timestamps to RFC 3339 strings, so the result holds the same kinds of values as a decoded
+// JSON document, except that integers are kept as int.
+func decodeYAML(data []byte) (map[string]any, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return make(map[string]any), nil
+	}
+	doc = normalizeValue(doc)
+	keyvals, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: YAML document must be a mapping, got %s", typeName(doc))
+	}
+	return keyvals, nil
+}
+
+// encodeYAML encodes keyvals as a YAML document.
+func encodeYAML(keyvals map[string]any) ([]byte, error) {
+	return yaml.Marshal(keyvals)
+}
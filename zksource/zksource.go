@@ -0,0 +1,186 @@
+// Package zksource provides a configuration provider reading the znodes below a path of a
+// ZooKeeper ensemble and watching them for changes:
+//
+//	conn, _, err := zk.Connect(servers, 10*time.Second)
+//	src := zksource.New(conn, "/myapp/config")
+//	err = config.Config().StartWatch(ctx, src)
+//
+// The znode tree below the path maps to nested configuration keys, so the znode
+// "/myapp/config/db/host" sets "host" inside the object "db". Only the data of znodes without
+// children is used, decoded with config.ParseValue.
+package zksource
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/go-zookeeper/zk"
+
+	"github.com/Carl-Frankenfeld/config"
+)
+
+// Source is a config.Provider reading the znodes below a path of a ZooKeeper ensemble.
+type Source struct {
+	conn *zk.Conn
+	root string
+}
+
+// New returns a Source reading the znodes below root using conn.
+func New(conn *zk.Conn, root string) *Source {
+	return &Source{conn: conn, root: root}
+}
+
+// Name identifies the source by its path.
+func (s *Source) Name() string {
+	return "zookeeper:" + s.root
+}
+
+// Load reads the current data of the znodes below the path.
+func (s *Source) Load(ctx context.Context) (map[string]any, error) {
+	return s.read(ctx, s.root, nil)
+}
+
+// watchKind distinguishes the data and child watches ZooKeeper keeps per znode.
+type watchKind int
+
+const (
+	dataWatch watchKind = iota
+	childWatch
+)
+
+// watch identifies a watch set on a znode.
+type watch struct {
+	path string
+	kind watchKind
+}
+
+// fired is a watch that was triggered by ev.
+type fired struct {
+	watch
+	ev zk.Event
+}
+
+// watcher sets ZooKeeper watches while reading a znode tree. ZooKeeper watches trigger once, so
+// every read sets watches on the znodes that have none left.
+type watcher struct {
+	ctx    context.Context
+	set    map[watch]bool
+	events chan fired
+}
+
+// add forwards the event of the watch w to the events channel.
+func (w *watcher) add(kw watch, ch <-chan zk.Event) {
+	w.set[kw] = true
+	go func() {
+		select {
+		case ev := <-ch:
+			select {
+			case w.events <- fired{kw, ev}:
+			case <-w.ctx.Done():
+			}
+		case <-w.ctx.Done():
+		}
+	}()
+}
+
+// Watch reports the document once the watches are set and whenever a znode below the path is
+// created, deleted or changed afterwards.
+func (s *Source) Watch(ctx context.Context, fn func(map[string]any)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := &watcher{ctx: ctx, set: make(map[watch]bool), events: make(chan fired)}
+	// the znodes may have changed before the watches were set
+	doc, err := s.read(ctx, s.root, w)
+	if err != nil {
+		return err
+	}
+	fn(doc)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case f := <-w.events:
+			if f.ev.Err != nil {
+				return f.ev.Err
+			}
+			if f.ev.Type == zk.EventNotWatching {
+				return errors.New("zookeeper session lost its watches")
+			}
+			delete(w.set, f.watch)
+			doc, err := s.read(ctx, s.root, w)
+			if err != nil {
+				return err
+			}
+			fn(doc)
+		}
+	}
+}
+
+// read reads the znode tree below p, setting the missing watches of w if it is not nil.
+func (s *Source) read(ctx context.Context, p string, w *watcher) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	children, err := s.children(p, w)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, len(children))
+	for _, child := range children {
+		cp := path.Join(p, child)
+		sub, err := s.children(cp, w)
+		if errors.Is(err, zk.ErrNoNode) {
+			// deleted since listing its parent, whose watch reports it
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(sub) > 0 {
+			if doc[child], err = s.read(ctx, cp, w); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		data, err := s.data(cp, w)
+		if errors.Is(err, zk.ErrNoNode) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc[child] = config.ParseValue(data)
+	}
+	return doc, nil
+}
+
+// children lists the children of the znode p, setting a child watch if w misses one.
+func (s *Source) children(p string, w *watcher) ([]string, error) {
+	kw := watch{p, childWatch}
+	if w == nil || w.set[kw] {
+		children, _, err := s.conn.Children(p)
+		return children, err
+	}
+	children, _, ch, err := s.conn.ChildrenW(p)
+	if err != nil {
+		return nil, err
+	}
+	w.add(kw, ch)
+	return children, nil
+}
+
+// data reads the data of the znode p, setting a data watch if w misses one.
+func (s *Source) data(p string, w *watcher) ([]byte, error) {
+	kw := watch{p, dataWatch}
+	if w == nil || w.set[kw] {
+		data, _, err := s.conn.Get(p)
+		return data, err
+	}
+	data, _, ch, err := s.conn.GetW(p)
+	if err != nil {
+		return nil, err
+	}
+	w.add(kw, ch)
+	return data, nil
+}